@@ -0,0 +1,294 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"f/helper"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// maxBatchArgBytes bounds the length of the paths passed to one --exec-batch
+// command, staying well below the kernel's argument size limit.
+const maxBatchArgBytes = 128 * 1024
+
+// resultOutput decides what happens to search results: printing them one per
+// line or NUL separated, or running an action on them.
+type resultOutput struct {
+	print0    bool
+	exec      []string
+	execBatch []string
+	then      []string
+	force     bool
+}
+
+// addResultFlags defines the output and action flags for commands that print paths.
+func addResultFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP("print0", "0", false, "Separate results with NUL bytes instead of newlines")
+	cmd.Flags().String("exec", "", "Run a command for each result; {} is replaced by the path")
+	cmd.Flags().String("exec-batch", "", "Run a command once with all results; {} is replaced by the paths")
+	cmd.Flags().String("then", "", "Run a built-in action on the results: 'copy <dst>', 'move <dst>' or 'delete'")
+	cmd.Flags().BoolP("force", "f", false, "Delete without asking for confirmation when using --then delete")
+}
+
+// resultOutputFromFlags reads the flags defined by addResultFlags.
+func resultOutputFromFlags(cmd *cobra.Command) (*resultOutput, error) {
+	out := &resultOutput{}
+	var err error
+	if out.print0, err = cmd.Flags().GetBool("print0"); err != nil {
+		return nil, err
+	}
+	if out.force, err = cmd.Flags().GetBool("force"); err != nil {
+		return nil, err
+	}
+	actions := 0
+	for name, argv := range map[string]*[]string{"exec": &out.exec, "exec-batch": &out.execBatch, "then": &out.then} {
+		value, err := cmd.Flags().GetString(name)
+		if err != nil {
+			return nil, err
+		}
+		if value == "" {
+			continue
+		}
+		if *argv, err = splitCommand(value); err != nil {
+			return nil, fmt.Errorf("invalid --%s: %w", name, err)
+		}
+		actions++
+	}
+	if actions > 1 {
+		return nil, errors.New("only one of --exec, --exec-batch and --then can be used")
+	}
+	if len(out.then) > 0 {
+		switch {
+		case (out.then[0] == "copy" || out.then[0] == "move") && len(out.then) == 2:
+		case out.then[0] == "delete" && len(out.then) == 1:
+		default:
+			return nil, fmt.Errorf("invalid --then %q (expected 'copy <dst>', 'move <dst>' or 'delete')", strings.Join(out.then, " "))
+		}
+	}
+	return out, nil
+}
+
+// pathsOnly reports whether the results must be plain paths, as opposed to
+// matching lines in content search.
+func (o *resultOutput) pathsOnly() bool {
+	return o.print0 || len(o.exec) > 0 || len(o.execBatch) > 0 || len(o.then) > 0
+}
+
+// handle prints results or runs the requested action on them. If format is
+// non-nil it is used to style each result printed one per line.
+func (o *resultOutput) handle(ctx context.Context, results []string, dir string, format func(string, string) string) error {
+	switch {
+	case len(o.exec) > 0:
+		return runEach(ctx, o.exec, results)
+	case len(o.execBatch) > 0:
+		return runBatch(ctx, o.execBatch, results)
+	case len(o.then) > 0:
+		return o.runThen(ctx, results)
+	case o.print0:
+		for _, result := range results {
+			fmt.Print(result, "\x00")
+		}
+		return nil
+	}
+	for _, result := range results {
+		if format != nil {
+			result = format(result, dir)
+		}
+		fmt.Println(result)
+	}
+	return nil
+}
+
+// splitCommand splits a command line into words. Words are separated by spaces,
+// and single quotes, double quotes and backslashes work like in a POSIX shell.
+// No other shell syntax is interpreted.
+func splitCommand(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else if r == '\\' {
+				escaped = true
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inWord = true, true
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote or escape")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	if len(words) == 0 {
+		return nil, errors.New("empty command")
+	}
+	return words, nil
+}
+
+// expandCommand replaces {} in argv with paths. A word that is exactly {} is
+// replaced by every path as separate arguments; {} inside a longer word is
+// replaced by the paths joined with spaces. If argv has no {}, the paths are appended.
+func expandCommand(argv []string, paths []string) []string {
+	var expanded []string
+	replaced := false
+	for _, arg := range argv {
+		switch {
+		case arg == "{}":
+			expanded = append(expanded, paths...)
+			replaced = true
+		case strings.Contains(arg, "{}"):
+			expanded = append(expanded, strings.ReplaceAll(arg, "{}", strings.Join(paths, " ")))
+			replaced = true
+		default:
+			expanded = append(expanded, arg)
+		}
+	}
+	if !replaced {
+		expanded = append(expanded, paths...)
+	}
+	return expanded
+}
+
+// runCommand runs argv with the standard streams of f.
+func runCommand(argv []string) error {
+	command := exec.Command(argv[0], argv[1:]...)
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	return command.Run()
+}
+
+// runEach runs argv once per path, until ctx is cancelled.
+func runEach(ctx context.Context, argv []string, paths []string) error {
+	var results batch
+	for i, path := range paths {
+		if ctx.Err() != nil {
+			results.skip(len(paths) - i)
+			break
+		}
+		if err := runCommand(expandCommand(argv, []string{path})); err != nil {
+			results.add(&pathError{action: "running " + argv[0] + " on", path: path, err: err})
+		} else {
+			results.add(nil)
+		}
+	}
+	return results.err()
+}
+
+// runBatch runs argv with as many paths per invocation as fit in
+// maxBatchArgBytes, until ctx is cancelled.
+func runBatch(ctx context.Context, argv []string, paths []string) error {
+	for start := 0; start < len(paths); {
+		if ctx.Err() != nil {
+			var results batch
+			results.skip(len(paths) - start)
+			return results.err()
+		}
+		end, size := start, 0
+		for end < len(paths) && (end == start || size+len(paths[end])+1 <= maxBatchArgBytes) {
+			size += len(paths[end]) + 1
+			end++
+		}
+		if err := runCommand(expandCommand(argv, paths[start:end])); err != nil {
+			return fmt.Errorf("error running %s: %w", argv[0], err)
+		}
+		start = end
+	}
+	return nil
+}
+
+// outermostPaths drops paths inside another path of the list, so directories
+// are acted on once as a whole rather than again entry by entry.
+func outermostPaths(paths []string) []string {
+	sorted := append([]string(nil), paths...)
+	sort.Strings(sorted)
+	var kept []string
+	for _, path := range sorted {
+		if len(kept) > 0 {
+			last := kept[len(kept)-1]
+			if path == last || strings.HasPrefix(path, strings.TrimSuffix(last, string(filepath.Separator))+string(filepath.Separator)) {
+				continue
+			}
+		}
+		kept = append(kept, path)
+	}
+	return kept
+}
+
+// runThen runs a built-in copy, move or delete action on paths with jobs
+// workers, until ctx is cancelled. Paths are used as they are rather than
+// expanded as wildcards.
+func (o *resultOutput) runThen(ctx context.Context, paths []string) error {
+	paths = outermostPaths(paths)
+	action := o.then[0]
+	if action == "delete" && !o.force {
+		for _, path := range paths {
+			fmt.Println(path)
+		}
+		fmt.Printf("Are you sure you want to delete these %d entries? (y/n): ", len(paths))
+		input, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("reading input: %w", err)
+		}
+		if input != "y\n" && input != "Y\n" {
+			return newError(kindCancelled, "deletion cancelled")
+		}
+	}
+	var results batch
+	switch action {
+	case "copy":
+		err := helper.CopyPaths(ctx, paths, o.then[1], false, false, jobs)
+		results.addAll("copying", o.then[1], paths, err, func(path string) {
+			fmt.Println(style.Success(fmt.Sprintf("Copied %s to %s successfully", path, o.then[1])))
+		})
+	case "move":
+		err := helper.CopyPaths(ctx, paths, o.then[1], true, false, jobs)
+		results.addAll("moving", o.then[1], paths, err, func(path string) {
+			fmt.Println(style.Success(fmt.Sprintf("Moved %s to %s successfully", path, o.then[1])))
+		})
+	default:
+		// The whole list was confirmed above, so the paths aren't asked about one by one
+		err := helper.DeletePaths(ctx, paths, true, jobs)
+		results.addAll("deleting", "", paths, err, func(path string) {
+			fmt.Println(style.Success(fmt.Sprintf("Deleted %s successfully", path)))
+		})
+	}
+	return results.err()
+}
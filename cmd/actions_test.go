@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestSplitCommand checks word splitting and quoting.
+func TestSplitCommand(t *testing.T) {
+	cases := map[string][]string{
+		"wc -l {}":                {"wc", "-l", "{}"},
+		`sh -c 'echo "$1"' _ {}`:  {"sh", "-c", `echo "$1"`, "_", "{}"},
+		`echo "a b" c\ d`:         {"echo", "a b", "c d"},
+		`copy "/tmp/my backups"`:  {"copy", "/tmp/my backups"},
+		"  delete  ":              {"delete"},
+		`printf '%s\n' "x\"y" ''`: {"printf", `%s\n`, `x"y`, ""},
+	}
+	for in, want := range cases {
+		got, err := splitCommand(in)
+		if err != nil {
+			t.Fatalf("splitCommand(%q) returned error: %v", in, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("splitCommand(%q) = %q, want %q", in, got, want)
+		}
+	}
+	for _, in := range []string{"", "   ", `echo "open`, `echo \`} {
+		if _, err := splitCommand(in); err == nil {
+			t.Fatalf("expected error for %q", in)
+		}
+	}
+}
+
+// TestExpandCommand checks placeholder replacement.
+func TestExpandCommand(t *testing.T) {
+	paths := []string{"a.txt", "b.txt"}
+	if got := expandCommand([]string{"rm", "{}"}, paths); !reflect.DeepEqual(got, []string{"rm", "a.txt", "b.txt"}) {
+		t.Fatalf("unexpected expansion: %q", got)
+	}
+	if got := expandCommand([]string{"wc", "-l"}, paths); !reflect.DeepEqual(got, []string{"wc", "-l", "a.txt", "b.txt"}) {
+		t.Fatalf("expected paths to be appended: %q", got)
+	}
+	if got := expandCommand([]string{"echo", "file={}"}, paths[:1]); !reflect.DeepEqual(got, []string{"echo", "file=a.txt"}) {
+		t.Fatalf("unexpected expansion: %q", got)
+	}
+}
+
+// TestOutermostPaths ensures entries inside a listed directory are dropped.
+func TestOutermostPaths(t *testing.T) {
+	got := outermostPaths([]string{"/a/b/c.txt", "/a/b", "/a/bc", "/a/b/d", "/x"})
+	want := []string{"/a/b", "/a/bc", "/x"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("outermostPaths = %q, want %q", got, want)
+	}
+}
+
+// TestRunNameSearch_ThenCopyAndDelete verifies the built-in actions on search results.
+func TestRunNameSearch_ThenCopyAndDelete(t *testing.T) {
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	dst := filepath.Join(td, "dst")
+	if err := os.Mkdir(src, 0o755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	for _, name := range []string{"a[1].log", "b.log", "keep.txt"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(name), 0o644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	cmd := newNameSearchTestCmd()
+	cmd.Flags().Set("then", "copy "+dst)
+	out := captureOutput(func() {
+		if err := runNameSearch(cmd, []string{".log", src}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !contains(out, "Copied") {
+		t.Fatalf("expected copy status lines; got: %q", out)
+	}
+	for _, name := range []string{"a[1].log", "b.log"} {
+		if _, err := os.Stat(filepath.Join(dst, name)); err != nil {
+			t.Fatalf("expected %s to be copied: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst, "keep.txt")); !os.IsNotExist(err) {
+		t.Fatalf("keep.txt should not be copied")
+	}
+
+	cmd = newNameSearchTestCmd()
+	cmd.Flags().Set("then", "delete")
+	cmd.Flags().Set("force", "true")
+	captureOutput(func() {
+		if err := runNameSearch(cmd, []string{".log", src}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	entries, _ := os.ReadDir(src)
+	if len(entries) != 1 || entries[0].Name() != "keep.txt" {
+		t.Fatalf("expected only keep.txt to remain, got %v", entries)
+	}
+}
+
+// TestRunFind_Print0AndExec verifies NUL-separated output and --exec.
+func TestRunFind_Print0AndExec(t *testing.T) {
+	td := t.TempDir()
+	name := filepath.Join(td, "line\nbreak.txt")
+	if err := os.WriteFile(name, []byte("x"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	cmd := newFindTestCmd()
+	cmd.Flags().Set("print0", "true")
+	out := captureOutput(func() {
+		if err := runFind(cmd, []string{td}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if out != name+"\x00" {
+		t.Fatalf("expected a single NUL-terminated path; got: %q", out)
+	}
+
+	cmd = newFindTestCmd()
+	cmd.Flags().Set("exec", "echo found: {}")
+	out = captureOutput(func() {
+		if err := runFind(cmd, []string{td}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !strings.HasPrefix(out, "found: "+name) {
+		t.Fatalf("expected echo output; got: %q", out)
+	}
+
+	cmd = newFindTestCmd()
+	cmd.Flags().Set("exec", "echo")
+	cmd.Flags().Set("then", "delete")
+	if err := runFind(cmd, []string{td}); err == nil {
+		t.Fatalf("expected an error when combining --exec and --then")
+	}
+}
+
+// TestRunBatch_Cancelled verifies that no command is started once the context is cancelled.
+func TestRunBatch_Cancelled(t *testing.T) {
+	td := t.TempDir()
+	marker := filepath.Join(td, "ran")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := runBatch(ctx, []string{"touch", marker}, []string{filepath.Join(td, "a"), filepath.Join(td, "b")})
+	if errorKindOf(err) != kindCancelled {
+		t.Fatalf("expected a cancelled error, got %v", err)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatalf("expected the command not to run after cancellation")
+	}
+}
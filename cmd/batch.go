@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"errors"
+	"f/helper"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// batch tracks a command that works through several paths and carries on
+// after a failure. The failures are reported together once the command is done.
+type batch struct {
+	succeeded int
+	failed    []error
+	// skipped counts the paths never started because the command was interrupted.
+	skipped int
+}
+
+// add records the result of one path.
+func (b *batch) add(err error) {
+	if err != nil {
+		b.failed = append(b.failed, err)
+	} else {
+		b.succeeded++
+	}
+}
+
+// expand expands the wildcards in patterns. A pattern that is malformed or
+// matches nothing is recorded as a failure.
+func (b *batch) expand(patterns []string) []string {
+	var paths []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			b.add(&pathError{action: "expanding", path: pattern, err: err})
+			continue
+		}
+		if len(matches) == 0 {
+			b.add(&pathError{action: "matching", path: pattern, err: errNoMatches})
+			continue
+		}
+		paths = append(paths, matches...)
+	}
+	return paths
+}
+
+// skip records n paths that were never started because the command was interrupted.
+func (b *batch) skip(n int) {
+	b.skipped += n
+}
+
+// addAll records the result of a helper that worked through paths. The paths
+// listed in a *helper.BatchError failed or were skipped and the rest worked;
+// any other error means every path failed. onSuccess is called for each path
+// that worked.
+func (b *batch) addAll(action, to string, paths []string, err error, onSuccess func(path string)) {
+	failures := map[string]error{}
+	skipped := map[string]bool{}
+	var batchErr *helper.BatchError
+	if errors.As(err, &batchErr) {
+		for _, failure := range batchErr.Failed {
+			failures[failure.Path] = failure.Err
+		}
+		for _, path := range batchErr.Skipped {
+			skipped[path] = true
+		}
+	}
+	for _, path := range paths {
+		if skipped[path] {
+			b.skip(1)
+			continue
+		}
+		cause, failed := failures[path]
+		if batchErr == nil && err != nil {
+			cause, failed = err, true
+		}
+		if failed {
+			b.add(&pathError{action: action, path: path, to: to, err: cause})
+			continue
+		}
+		b.add(nil)
+		onSuccess(path)
+	}
+}
+
+// err summarizes the batch: nil if every path worked, the failure itself if
+// the batch was a single path, and otherwise a *batchError listing every failure.
+func (b *batch) err() error {
+	switch {
+	case len(b.failed) == 0 && b.skipped == 0:
+		return nil
+	case len(b.failed) == 1 && b.succeeded == 0 && b.skipped == 0:
+		return b.failed[0]
+	}
+	return &batchError{succeeded: b.succeeded, failed: b.failed, skipped: b.skipped}
+}
+
+// batchError is returned by a command for which more than one path was
+// attempted and at least one failed, or that was interrupted.
+type batchError struct {
+	succeeded int
+	failed    []error
+	skipped   int
+}
+
+// summary counts the paths that worked and failed, as in "487 succeeded, 13 failed".
+func (e *batchError) summary() string {
+	summary := fmt.Sprintf("%d succeeded, %d failed", e.succeeded, len(e.failed))
+	if e.skipped > 0 {
+		summary += fmt.Sprintf(", %d skipped after an interrupt", e.skipped)
+	}
+	return summary
+}
+
+func (e *batchError) Error() string {
+	if len(e.failed) == 0 {
+		return e.summary()
+	}
+	lines := make([]string, len(e.failed))
+	for i, err := range e.failed {
+		lines[i] = err.Error()
+	}
+	return fmt.Sprintf("%s:\n  %s", e.summary(), strings.Join(lines, "\n  "))
+}
+
+func (e *batchError) Unwrap() []error { return e.failed }
+
+// kind is cancelled if the batch was interrupted, partial if some paths
+// worked, and otherwise the kind the failures share, or a plain failure if
+// they differ.
+func (e *batchError) kind() errorKind {
+	if e.skipped > 0 {
+		return kindCancelled
+	}
+	if e.succeeded > 0 {
+		return kindPartial
+	}
+	kind := errorKindOf(e.failed[0])
+	for _, err := range e.failed[1:] {
+		if errorKindOf(err) != kind {
+			return kindFailure
+		}
+	}
+	return kind
+}
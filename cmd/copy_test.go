@@ -9,22 +9,20 @@ import (
 	"github.com/spf13/cobra"
 )
 
-// TestRunCopy_Usage_NoArgs verifies that running the copy command with no arguments displays the usage message.
+// TestRunCopy_Usage_NoArgs verifies that running the copy command with no arguments returns a usage error.
 func TestRunCopy_Usage_NoArgs(t *testing.T) {
 	cmd := &cobra.Command{}
 	// must define the flag so GetBool won't error
 	cmd.Flags().BoolP("overwrite", "o", false, "Overwrite")
 
-	out := captureOutput(func() {
-		runCopy(cmd, []string{})
-	})
+	err := runCopy(cmd, []string{})
 
-	if !contains(out, "Usage: copy") {
-		t.Fatalf("expected usage message, got: %q", out)
+	if err == nil || !contains(err.Error(), "usage: copy") {
+		t.Fatalf("expected usage error, got: %v", err)
 	}
 }
 
-// TestRunCopy_NoMatches verifies that running the copy command with no matches displays the no-match message.
+// TestRunCopy_NoMatches verifies that running the copy command with no matches returns a not-found error.
 func TestRunCopy_NoMatches(t *testing.T) {
 	td := t.TempDir()
 	dst := filepath.Join(td, "dst")
@@ -35,12 +33,10 @@ func TestRunCopy_NoMatches(t *testing.T) {
 
 	pattern := filepath.Join(td, "no_such_*")
 
-	out := captureOutput(func() {
-		runCopy(cmd, []string{pattern, dst})
-	})
+	err := runCopy(cmd, []string{pattern, dst})
 
-	if !contains(out, "No files matched the source pattern") {
-		t.Fatalf("expected no-match message, got: %q", out)
+	if err == nil || !contains(err.Error(), "no files matched the source pattern") || exitCodeOf(err) != exitNotFound {
+		t.Fatalf("expected a not-found error, got: %v", err)
 	}
 }
 
@@ -113,3 +109,37 @@ func TestRunCopy_CopyDirSuccess(t *testing.T) {
 		t.Fatalf("destination file content mismatch: got %q want %q", string(got), string(content))
 	}
 }
+
+// TestRunCopy_PartialFailureSummary verifies that copying carries on past a
+// failed file and reports every failure in a final summary.
+func TestRunCopy_PartialFailureSummary(t *testing.T) {
+	td := t.TempDir()
+	dstDir := filepath.Join(td, "dst")
+	_ = os.MkdirAll(dstDir, 0o755)
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(td, name), []byte(name), 0o644); err != nil {
+			t.Fatalf("failed to write source file: %v", err)
+		}
+	}
+	// b.txt and c.txt already exist at the destination
+	_ = os.WriteFile(filepath.Join(dstDir, "b.txt"), []byte("old"), 0o644)
+	_ = os.WriteFile(filepath.Join(dstDir, "c.txt"), []byte("old"), 0o644)
+
+	cmd := &cobra.Command{}
+	cmd.Flags().BoolP("overwrite", "o", false, "Overwrite")
+
+	var err error
+	out := captureOutput(func() {
+		err = runCopy(cmd, []string{filepath.Join(td, "*.txt"), dstDir})
+	})
+
+	if !contains(out, "a.txt") {
+		t.Fatalf("expected a.txt to be copied, got: %q", out)
+	}
+	if err == nil || exitCodeOf(err) != exitPartial || !contains(err.Error(), "1 succeeded, 2 failed") {
+		t.Fatalf("expected a partial failure summary, got: %v", err)
+	}
+	if !contains(err.Error(), filepath.Join(td, "b.txt")) || !contains(err.Error(), filepath.Join(td, "c.txt")) {
+		t.Fatalf("expected both failed paths to be listed, got: %v", err)
+	}
+}
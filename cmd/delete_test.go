@@ -8,18 +8,16 @@ import (
 	"github.com/spf13/cobra"
 )
 
-// TestRunDelete_Usage_NoArgs verifies that running the delete command with no arguments displays the usage message.
+// TestRunDelete_Usage_NoArgs verifies that running the delete command with no arguments returns a usage error.
 func TestRunDelete_Usage_NoArgs(t *testing.T) {
 	cmd := &cobra.Command{}
 	// define the flag so GetBool won't error
 	cmd.Flags().BoolP("force", "f", false, "Force")
 
-	out := captureOutput(func() {
-		runDelete(cmd, []string{})
-	})
+	err := runDelete(cmd, []string{})
 
-	if !contains(out, "Usage: delete") {
-		t.Fatalf("expected usage message, got: %q", out)
+	if err == nil || !contains(err.Error(), "usage: delete") {
+		t.Fatalf("expected usage error, got: %v", err)
 	}
 }
 
@@ -31,12 +29,10 @@ func TestRunDelete_NoMatches(t *testing.T) {
 
 	pattern := filepath.Join(td, "no_such_*")
 
-	out := captureOutput(func() {
-		runDelete(cmd, []string{pattern})
-	})
+	err := runDelete(cmd, []string{pattern})
 
-	if !contains(out, "No files matched the source pattern") {
-		t.Fatalf("expected no-match message, got: %q", out)
+	if err == nil || !contains(err.Error(), "no files matched the source pattern") || exitCodeOf(err) != exitNotFound {
+		t.Fatalf("expected a not-found error, got: %v", err)
 	}
 }
 
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"f/helper"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// barWidth is the number of cells in the percentage bar.
+const barWidth = 20
+
+// usageBar draws a bar showing part as a share of total.
+func usageBar(part, total int64) string {
+	filled := 0
+	if total > 0 {
+		filled = min(max(int(float64(part)/float64(total)*barWidth), 0), barWidth)
+	}
+	return "[" + strings.Repeat("█", filled) + strings.Repeat(" ", barWidth-filled) + "]"
+}
+
+// printUsage prints one row per entry with sizes relative to total.
+func printUsage(entries []helper.UsageEntry, root string, total int64, apparent bool) {
+	fmt.Printf("%-12s %-12s %-7s %-*s %s\n", "Apparent", "Allocated", "Percent", barWidth+2, "Usage", "Path")
+	for _, entry := range entries {
+		percent := 0.0
+		if total > 0 {
+			percent = float64(entry.Size(apparent)) / float64(total) * 100
+		}
+		path, err := filepath.Rel(root, entry.Path)
+		if err != nil {
+			path = entry.Path
+		}
+		fmt.Printf("%-12s %-12s %6.1f%% %s %s\n",
+			formatSize(entry.Apparent),
+			formatSize(entry.Allocated),
+			percent,
+			usageBar(entry.Size(apparent), total),
+			path)
+	}
+}
+
+func runDu(cmd *cobra.Command, args []string) error {
+	depth, err := cmd.Flags().GetInt("depth")
+	if err != nil {
+		return err
+	}
+
+	top, err := cmd.Flags().GetInt("top")
+	if err != nil {
+		return err
+	}
+
+	apparent, err := cmd.Flags().GetBool("apparent-size")
+	if err != nil {
+		return err
+	}
+
+	threads, err := cmd.Flags().GetInt("threads")
+	if err != nil {
+		return err
+	}
+
+	dir, err := helper.GetDirectoryFromArgs(args, 1)
+	if err != nil {
+		return err
+	}
+
+	report, err := helper.DiskUsage(commandContext(cmd), dir, threads)
+	if err := handleWalkError(err); err != nil {
+		return err
+	}
+	total := report.Root().Size(apparent)
+
+	// Without --top, show the directory breakdown like du -d
+	if top <= 0 {
+		dirs := report.Directories(depth)
+		helper.SortUsage(dirs, apparent)
+		printUsage(dirs, dir, total, apparent)
+		return nil
+	}
+
+	fmt.Printf("Largest %d entries in %s (total %s)\n", top, dir, formatSize(total))
+	printUsage(report.Largest(top, apparent), dir, total, apparent)
+	return nil
+}
+
+var duCmd = &cobra.Command{
+	Use:   "du [directory]",
+	Short: "Show disk usage of a directory",
+	Long: `Show the cumulative disk usage of a directory and its subdirectories, sorted from largest to smallest.
+If no directory is specified, the current directory is used.`,
+	RunE: runDu,
+}
+
+func init() {
+	duCmd.Flags().IntP("depth", "d", 1, "Show subdirectories up to this many levels deep (-1 for all)")
+	duCmd.Flags().IntP("top", "n", 0, "Show only the N largest files and directories")
+	duCmd.Flags().BoolP("apparent-size", "b", false, "Sort and compute percentages by apparent size instead of allocated size")
+	addThreadsFlag(duCmd)
+}
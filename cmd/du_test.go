@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// newDuTestCmd returns a command with the du flags defined.
+func newDuTestCmd(top int) *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Flags().IntP("depth", "d", 1, "Depth")
+	cmd.Flags().IntP("top", "n", top, "Top")
+	cmd.Flags().BoolP("apparent-size", "b", true, "Apparent size")
+	addThreadsFlag(cmd)
+	return cmd
+}
+
+// TestRunDu_ShowsSubdirectories verifies that subdirectories are listed with percentages.
+func TestRunDu_ShowsSubdirectories(t *testing.T) {
+	td := t.TempDir()
+	sub := filepath.Join(td, "logs")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatalf("failed to create subdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, "big.log"), make([]byte, 2048), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	out := captureOutput(func() {
+		runDu(newDuTestCmd(0), []string{td})
+	})
+
+	if !contains(out, "logs") || !contains(out, "100.0%") {
+		t.Fatalf("expected usage rows for root and logs; got: %q", out)
+	}
+}
+
+// TestRunDu_Top verifies that --top lists the largest files.
+func TestRunDu_Top(t *testing.T) {
+	td := t.TempDir()
+	if err := os.WriteFile(filepath.Join(td, "large.bin"), make([]byte, 4096), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(td, "small.bin"), make([]byte, 10), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	out := captureOutput(func() {
+		runDu(newDuTestCmd(1), []string{td})
+	})
+
+	if !contains(out, "large.bin") || contains(out, "small.bin") {
+		t.Fatalf("expected only the largest file to be listed; got: %q", out)
+	}
+}
+
+// TestRunDu_Interrupted verifies that an interrupted du reports the interrupt
+// instead of partial sizes.
+func TestRunDu_Interrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cmd := newDuTestCmd(0)
+	cmd.SetContext(ctx)
+
+	var err error
+	out := captureOutput(func() {
+		err = runDu(cmd, []string{t.TempDir()})
+	})
+	if !errors.Is(err, errInterrupted) || out != "" {
+		t.Fatalf("expected the interrupt to be reported without output, got %v and %q", err, out)
+	}
+}
+
+// TestUsageBar verifies that shares outside 0 to 100% are clamped.
+func TestUsageBar(t *testing.T) {
+	for _, part := range []int64{-10, 0, 50, 100, 250} {
+		if bar := usageBar(part, 100); len([]rune(bar)) != barWidth+2 {
+			t.Fatalf("usageBar(%d, 100) = %q, want %d cells", part, bar, barWidth)
+		}
+	}
+}
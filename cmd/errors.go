@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"f/helper"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// Exit codes used by Execute. They are part of the documented interface, so
+// scripts can tell failures apart.
+const (
+	exitFailure    = 1
+	exitPartial    = 2
+	exitNotFound   = 3
+	exitExists     = 4
+	exitPermission = 5
+	exitCancelled  = 130
+)
+
+// errorKind classifies the errors a command fails with.
+type errorKind string
+
+const (
+	kindFailure    errorKind = "error"
+	kindPartial    errorKind = "partial"
+	kindNotFound   errorKind = "not_found"
+	kindExists     errorKind = "exists"
+	kindPermission errorKind = "permission"
+	kindCancelled  errorKind = "cancelled"
+)
+
+// exitCodes maps each kind of error to the status Execute exits with.
+var exitCodes = map[errorKind]int{
+	kindFailure:    exitFailure,
+	kindPartial:    exitPartial,
+	kindNotFound:   exitNotFound,
+	kindExists:     exitExists,
+	kindPermission: exitPermission,
+	kindCancelled:  exitCancelled,
+}
+
+// exitCode is the status Execute exits with when the command itself does not
+// fail. Commands raise it when they finish with warnings.
+var exitCode = 0
+
+// strictMode makes walk warnings fatal. It is set from the --strict flag.
+var strictMode = false
+
+// errorFormat is how errors are printed to stderr: "text" or "json". It is set
+// from the --error-format flag.
+var errorFormat = "text"
+
+// commandError is an error with an explicit kind, for failures whose kind
+// can't be told from the underlying error.
+type commandError struct {
+	kind errorKind
+	err  error
+}
+
+func (e *commandError) Error() string { return e.err.Error() }
+
+func (e *commandError) Unwrap() error { return e.err }
+
+// newError returns an error of the given kind with a formatted message.
+func newError(kind errorKind, format string, args ...any) error {
+	return &commandError{kind: kind, err: fmt.Errorf(format, args...)}
+}
+
+// errNoResults is returned when a search or find matches nothing.
+var errNoResults = newError(kindNotFound, "no files found for search criteria")
+
+// errCancelled is returned when the user declines a confirmation.
+var errCancelled = newError(kindCancelled, "cancelled")
+
+// errInterrupted is returned when a search is interrupted before it finished.
+var errInterrupted = newError(kindCancelled, "interrupted")
+
+// pathError is a failure on one of the paths a command works through. to is
+// the destination, for actions that have one.
+type pathError struct {
+	action string
+	path   string
+	to     string
+	err    error
+}
+
+func (e *pathError) Error() string {
+	if e.to != "" {
+		return fmt.Sprintf("%s %s to %s: %v", e.action, e.path, e.to, e.err)
+	}
+	return fmt.Sprintf("%s %s: %v", e.action, e.path, e.err)
+}
+
+func (e *pathError) Unwrap() error { return e.err }
+
+// errorKindOf classifies err, looking through its whole chain. An explicit
+// kind from newError wins; otherwise the kind comes from the cause.
+func errorKindOf(err error) errorKind {
+	var cmdErr *commandError
+	var batchErr *batchError
+	var walkErr *helper.WalkError
+	var collision *helper.CollisionError
+	switch {
+	case errors.As(err, &batchErr):
+		return batchErr.kind()
+	case errors.As(err, &cmdErr):
+		return cmdErr.kind
+	case errors.Is(err, helper.ErrCancelled), errors.Is(err, context.Canceled):
+		return kindCancelled
+	case errors.As(err, &walkErr):
+		return kindPartial
+	case errors.As(err, &collision), errors.Is(err, fs.ErrExist):
+		return kindExists
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, helper.ErrNotFound):
+		return kindNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return kindPermission
+	}
+	return kindFailure
+}
+
+// exitCodeOf returns the status to exit with after err.
+func exitCodeOf(err error) int {
+	return exitCodes[errorKindOf(err)]
+}
+
+// jsonError is an error or warning as printed with --error-format=json.
+type jsonError struct {
+	Level    string    `json:"level"`
+	Kind     errorKind `json:"kind"`
+	Message  string    `json:"message"`
+	Path     string    `json:"path,omitempty"`
+	ExitCode int       `json:"exit_code,omitempty"`
+	// Failures lists the paths a batch failed on.
+	Failures []jsonError `json:"failures,omitempty"`
+}
+
+// errorPath returns the path err is about, if it names one.
+func errorPath(err error) string {
+	var batchErr *batchError
+	if errors.As(err, &batchErr) {
+		return ""
+	}
+	var pathErr *pathError
+	var fsErr *fs.PathError
+	var linkErr *os.LinkError
+	switch {
+	case errors.As(err, &pathErr):
+		return pathErr.path
+	case errors.As(err, &fsErr):
+		return fsErr.Path
+	case errors.As(err, &linkErr):
+		return linkErr.Old
+	}
+	return ""
+}
+
+// printDiagnostic writes an error or warning to stderr, as text or as a JSON
+// object on one line.
+func printDiagnostic(level string, err error) {
+	if errorFormat == "json" {
+		diag := jsonError{Level: level, Kind: errorKindOf(err), Message: err.Error(), Path: errorPath(err)}
+		if level == "error" {
+			diag.ExitCode = exitCodeOf(err)
+		}
+		var batchErr *batchError
+		if errors.As(err, &batchErr) {
+			diag.Message = batchErr.summary()
+			for _, failure := range batchErr.failed {
+				diag.Failures = append(diag.Failures, jsonError{Level: level, Kind: errorKindOf(failure), Message: failure.Error(), Path: errorPath(failure)})
+			}
+		}
+		data, _ := json.Marshal(diag)
+		fmt.Fprintln(os.Stderr, string(data))
+		return
+	}
+	if level == "warning" {
+		fmt.Fprintln(os.Stderr, errStyle.Warning(fmt.Sprintf("Warning: %v", err)))
+		return
+	}
+	fmt.Fprintln(os.Stderr, errStyle.Failure(fmt.Sprintf("Error: %v", err)))
+}
+
+// printError writes err to stderr.
+func printError(err error) {
+	printDiagnostic("error", err)
+}
+
+// handleWalkError reports the per-path errors of a partial walk. The errors are
+// printed to stderr as warnings and the exit code is raised to exitPartial, and
+// nil is returned so the caller goes on to print its partial results. With
+// --strict, or for any error other than a *helper.WalkError, err is returned.
+// An interrupted walk returns errInterrupted, so nothing acts on its results.
+func handleWalkError(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return errInterrupted
+	}
+	var walkErr *helper.WalkError
+	if !errors.As(err, &walkErr) {
+		return err
+	}
+	for _, pathErr := range walkErr.Errors {
+		printDiagnostic("warning", pathErr)
+	}
+	if strictMode {
+		return &commandError{kind: kindFailure, err: err}
+	}
+	exitCode = max(exitCode, exitPartial)
+	return nil
+}
+
+// reportCommandError prints the error a command failed with and returns the
+// status to exit with.
+func reportCommandError(err error) int {
+	printError(err)
+	return exitCodeOf(err)
+}
+
+// errNoMatches is the cause reported for a source pattern that matched nothing.
+var errNoMatches = newError(kindNotFound, "no files matched the source pattern")
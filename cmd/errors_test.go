@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"f/helper"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// TestHandleWalkError checks that walk warnings raise the exit code, and only
+// fail the command with --strict.
+func TestHandleWalkError(t *testing.T) {
+	defer func() { exitCode, strictMode = 0, false }()
+
+	walkErr := &helper.WalkError{Errors: []error{errors.New("open locked: permission denied")}}
+	if err := handleWalkError(walkErr); err != nil {
+		t.Fatalf("expected warnings to be non-fatal, got %v", err)
+	}
+	if exitCode != exitPartial {
+		t.Fatalf("expected exit code %d, got %d", exitPartial, exitCode)
+	}
+
+	strictMode = true
+	if err := handleWalkError(walkErr); err == nil {
+		t.Fatalf("expected --strict to return the walk error")
+	}
+
+	other := errors.New("boom")
+	if err := handleWalkError(other); err != other {
+		t.Fatalf("expected other errors to be returned unchanged, got %v", err)
+	}
+}
+
+// TestErrorKindOf checks that errors are classified by their cause.
+func TestErrorKindOf(t *testing.T) {
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{errors.New("boom"), exitFailure},
+		{&pathError{action: "copying", path: "a", err: fs.ErrNotExist}, exitNotFound},
+		{&fs.PathError{Op: "open", Path: "a", Err: syscall.EACCES}, exitPermission},
+		{fmt.Errorf("wrapped: %w", &helper.CollisionError{Collisions: []string{"a"}}), exitExists},
+		{fmt.Errorf("deletion of a %w", helper.ErrCancelled), exitCancelled},
+		{&helper.WalkError{Errors: []error{fs.ErrPermission}}, exitPartial},
+		{newError(kindFailure, "explicit kinds win: %w", fs.ErrNotExist), exitFailure},
+	}
+	for _, c := range cases {
+		if got := exitCodeOf(c.err); got != c.want {
+			t.Fatalf("exitCodeOf(%v) = %d, want %d", c.err, got, c.want)
+		}
+	}
+}
+
+// TestBatchErr checks the summary of a batch of paths.
+func TestBatchErr(t *testing.T) {
+	var ok batch
+	ok.add(nil)
+	if err := ok.err(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var single batch
+	failure := &pathError{action: "deleting", path: "a", err: fs.ErrNotExist}
+	single.add(failure)
+	if err := single.err(); err != failure {
+		t.Fatalf("expected a single failure to be returned as is, got %v", err)
+	}
+
+	var partial batch
+	partial.add(nil)
+	partial.add(&pathError{action: "copying", path: "a", err: fs.ErrExist})
+	partial.add(&pathError{action: "copying", path: "b", err: fs.ErrPermission})
+	err := partial.err()
+	if exitCodeOf(err) != exitPartial {
+		t.Fatalf("expected a partial failure, got %v", err)
+	}
+	want := "1 succeeded, 2 failed:\n  copying a: file already exists\n  copying b: permission denied"
+	if err.Error() != want {
+		t.Fatalf("got %q, want %q", err.Error(), want)
+	}
+
+	var all batch
+	all.add(&pathError{action: "copying", path: "a", err: fs.ErrExist})
+	all.add(&pathError{action: "copying", path: "b", err: fs.ErrExist})
+	if err := all.err(); exitCodeOf(err) != exitExists {
+		t.Fatalf("expected every path to fail with exists, got %v", err)
+	}
+
+	var interrupted batch
+	interrupted.add(nil)
+	interrupted.skip(3)
+	err = interrupted.err()
+	if exitCodeOf(err) != exitCancelled || err.Error() != "1 succeeded, 0 failed, 3 skipped after an interrupt" {
+		t.Fatalf("expected an interrupted summary, got %v", err)
+	}
+}
+
+// TestBatchAddAll checks that the failures of a helper batch are matched to
+// their paths and the rest are counted as successes.
+func TestBatchAddAll(t *testing.T) {
+	paths := []string{"a", "b", "c"}
+	var results batch
+	var succeeded []string
+	helperErr := &helper.BatchError{Succeeded: []string{"a", "c"}, Failed: []helper.PathFailure{{Path: "b", Err: fs.ErrPermission}}}
+	results.addAll("copying", "dst", paths, helperErr, func(path string) {
+		succeeded = append(succeeded, path)
+	})
+	if !reflect.DeepEqual(succeeded, []string{"a", "c"}) {
+		t.Fatalf("expected a and c to succeed, got %v", succeeded)
+	}
+	err := results.err()
+	if err == nil || err.Error() != "2 succeeded, 1 failed:\n  copying b to dst: permission denied" {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	var interrupted batch
+	helperErr = &helper.BatchError{Succeeded: []string{"a"}, Skipped: []string{"b", "c"}, Cause: context.Canceled}
+	interrupted.addAll("copying", "dst", paths, helperErr, func(path string) {
+		if path != "a" {
+			t.Fatalf("expected skipped path %s not to count as a success", path)
+		}
+	})
+	if err := interrupted.err(); exitCodeOf(err) != exitCancelled || !contains(err.Error(), "2 skipped") {
+		t.Fatalf("expected an interrupted summary, got %v", err)
+	}
+
+	var whole batch
+	whole.addAll("copying", "dst", paths, fs.ErrPermission, func(path string) {
+		t.Fatalf("expected %s to fail", path)
+	})
+	if err := whole.err(); exitCodeOf(err) != exitPermission {
+		t.Fatalf("expected every path to fail with permission, got %v", err)
+	}
+}
+
+// TestPrintErrorJSON checks the --error-format=json output of a single
+// failure and of a batch summary.
+func TestPrintErrorJSON(t *testing.T) {
+	defer func() { errorFormat = "text" }()
+	errorFormat = "json"
+
+	var single batch
+	out := captureStderr(func() {
+		single.add(&pathError{action: "deleting", path: "a.txt", err: fs.ErrNotExist})
+		if code := reportCommandError(single.err()); code != exitNotFound {
+			t.Fatalf("expected exit code %d, got %d", exitNotFound, code)
+		}
+	})
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected the error to be printed once, got: %q", out)
+	}
+	var diag jsonError
+	if err := json.Unmarshal([]byte(lines[0]), &diag); err != nil {
+		t.Fatalf("invalid JSON %q: %v", lines[0], err)
+	}
+	want := jsonError{Level: "error", Kind: kindNotFound, Message: "deleting a.txt: file does not exist", Path: "a.txt", ExitCode: exitNotFound}
+	if !reflect.DeepEqual(diag, want) {
+		t.Fatalf("got %+v, want %+v", diag, want)
+	}
+
+	var partial batch
+	out = captureStderr(func() {
+		partial.add(nil)
+		partial.add(&pathError{action: "deleting", path: "b.txt", err: fs.ErrNotExist})
+		reportCommandError(partial.err())
+	})
+	diag = jsonError{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(out)), &diag); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out, err)
+	}
+	want = jsonError{Level: "error", Kind: kindPartial, Message: "1 succeeded, 1 failed", ExitCode: exitPartial, Failures: []jsonError{
+		{Level: "error", Kind: kindNotFound, Message: "deleting b.txt: file does not exist", Path: "b.txt"},
+	}}
+	if !reflect.DeepEqual(diag, want) {
+		t.Fatalf("got %+v, want %+v", diag, want)
+	}
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"f/helper"
+
+	"github.com/spf13/cobra"
+)
+
+func runFind(cmd *cobra.Command, args []string) error {
+	opts, err := walkOptions(cmd)
+	if err != nil {
+		return err
+	}
+	if opts.SortByPath, err = sortByPath(cmd, false); err != nil {
+		return err
+	}
+	output, err := resultOutputFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	dir, err := helper.GetDirectoryFromArgs(args, 1)
+	if err != nil {
+		return err
+	}
+	results, err := helper.Find(commandContext(cmd), dir, opts)
+	if err := handleWalkError(err); err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return errNoResults
+	}
+	return output.handle(commandContext(cmd), results, dir, nil)
+}
+
+var findCmd = &cobra.Command{
+	Use:   "find [directory]",
+	Short: "Find files by type, size, age and extension",
+	Long: `Find files and directories below a directory that match the given filters, without a name or content query.
+If no directory is specified, the current directory is used.`,
+	RunE: runFind,
+}
+
+func init() {
+	addIgnoreFlags(findCmd, true)
+	addSortFlag(findCmd)
+	addWalkFlags(findCmd)
+	addResultFlags(findCmd)
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// newFindTestCmd returns a command with the find flags defined.
+func newFindTestCmd() *cobra.Command {
+	cmd := &cobra.Command{}
+	addIgnoreFlags(cmd, true)
+	addSortFlag(cmd)
+	addWalkFlags(cmd)
+	addResultFlags(cmd)
+	return cmd
+}
+
+// TestRunFind_Filters verifies that find prints only entries passing the filters.
+func TestRunFind_Filters(t *testing.T) {
+	td := t.TempDir()
+	if err := os.WriteFile(filepath.Join(td, "big.bin"), make([]byte, 4096), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(td, "small.go"), []byte("package x"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(td, "empty"), 0o755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+
+	cmd := newFindTestCmd()
+	cmd.Flags().Set("min-size", "1KiB")
+	out := captureOutput(func() {
+		if err := runFind(cmd, []string{td}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !contains(out, "big.bin") || contains(out, "small.go") || contains(out, "empty") {
+		t.Fatalf("expected only big.bin; got: %q", out)
+	}
+
+	cmd = newFindTestCmd()
+	cmd.Flags().Set("type", "d")
+	cmd.Flags().Set("empty", "true")
+	out = captureOutput(func() {
+		if err := runFind(cmd, []string{td}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if strings.TrimSpace(out) != filepath.Join(td, "empty") {
+		t.Fatalf("expected only the empty directory; got: %q", out)
+	}
+}
+
+// TestRunFind_InvalidFilter ensures bad filter values are reported.
+func TestRunFind_InvalidFilter(t *testing.T) {
+	for flag, value := range map[string]string{"type": "x", "min-size": "lots", "newer": "soon"} {
+		cmd := newFindTestCmd()
+		cmd.Flags().Set(flag, value)
+		if err := runFind(cmd, []string{t.TempDir()}); err == nil {
+			t.Fatalf("expected an error for --%s %s", flag, value)
+		}
+	}
+}
+
+// TestRunNameSearch_WithFilter verifies that filters combine with the name query.
+func TestRunNameSearch_WithFilter(t *testing.T) {
+	td := t.TempDir()
+	for _, name := range []string{"notes.md", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(td, name), []byte("x"), 0o644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	cmd := newNameSearchTestCmd()
+	cmd.Flags().Set("ext", "md")
+	out := captureOutput(func() {
+		if err := runNameSearch(cmd, []string{"notes", td}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !contains(out, "notes.md") || contains(out, "notes.txt") {
+		t.Fatalf("expected only notes.md; got: %q", out)
+	}
+}
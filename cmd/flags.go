@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"f/helper"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// addIgnoreFlags defines the --respect-ignore and --no-ignore flags on cmd.
+func addIgnoreFlags(cmd *cobra.Command, respectByDefault bool) {
+	cmd.Flags().Bool("respect-ignore", respectByDefault, "Skip files excluded by .gitignore, .ignore and .git/info/exclude")
+	cmd.Flags().Bool("no-ignore", false, "Do not skip files excluded by ignore files")
+}
+
+// respectIgnore resolves the --respect-ignore and --no-ignore flags.
+func respectIgnore(cmd *cobra.Command) (bool, error) {
+	respect, err := cmd.Flags().GetBool("respect-ignore")
+	if err != nil {
+		return false, err
+	}
+	noIgnore, err := cmd.Flags().GetBool("no-ignore")
+	if err != nil {
+		return false, err
+	}
+	return respect && !noIgnore, nil
+}
+
+// addThreadsFlag defines the --threads flag for commands that walk directories.
+func addThreadsFlag(cmd *cobra.Command) {
+	cmd.Flags().Int("threads", 0, "Number of workers reading directories and files while walking (default: number of CPUs); copy, move and delete use --jobs instead")
+}
+
+// addFilterFlags defines the metadata filter flags for commands that walk directories.
+func addFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("type", "t", "", "Only match these types: f (file), d (directory), l (symlink), comma separated")
+	cmd.Flags().String("min-size", "", "Only match files at least this large, like 10MiB or 1.5GB")
+	cmd.Flags().String("max-size", "", "Only match files at most this large")
+	cmd.Flags().String("newer", "", "Only match entries modified after this age (like 7d or 12h) or date (like 2006-01-02)")
+	cmd.Flags().String("older", "", "Only match entries modified before this age or date")
+	cmd.Flags().Int("max-depth", 0, "Descend at most this many levels below the directory (0: no limit)")
+	cmd.Flags().Bool("empty", false, "Only match empty files and directories")
+	cmd.Flags().StringSlice("ext", nil, "Only match files with these extensions, like go,md")
+}
+
+// fileFilter builds a helper.FileFilter from the flags defined by addFilterFlags.
+func fileFilter(cmd *cobra.Command) (helper.FileFilter, error) {
+	filter := helper.FileFilter{}
+	types, err := cmd.Flags().GetString("type")
+	if err != nil {
+		return filter, err
+	}
+	if filter.Types, err = helper.ParseFileType(types); err != nil {
+		return filter, err
+	}
+	for name, size := range map[string]*int64{"min-size": &filter.MinSize, "max-size": &filter.MaxSize} {
+		value, err := cmd.Flags().GetString(name)
+		if err != nil {
+			return filter, err
+		}
+		if value == "" {
+			continue
+		}
+		if *size, err = helper.ParseSize(value); err != nil {
+			return filter, fmt.Errorf("invalid --%s: %w", name, err)
+		}
+	}
+	now := time.Now()
+	for name, bound := range map[string]*time.Time{"newer": &filter.NewerThan, "older": &filter.OlderThan} {
+		value, err := cmd.Flags().GetString(name)
+		if err != nil {
+			return filter, err
+		}
+		if value == "" {
+			continue
+		}
+		if *bound, err = helper.ParseTime(value, now); err != nil {
+			return filter, err
+		}
+	}
+	if filter.MaxDepth, err = cmd.Flags().GetInt("max-depth"); err != nil {
+		return filter, err
+	}
+	if filter.MaxDepth < 0 {
+		return filter, fmt.Errorf("invalid --max-depth %d", filter.MaxDepth)
+	}
+	if filter.Empty, err = cmd.Flags().GetBool("empty"); err != nil {
+		return filter, err
+	}
+	if filter.Extensions, err = cmd.Flags().GetStringSlice("ext"); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
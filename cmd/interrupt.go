@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+	"f/helper"
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+)
+
+// errInterrupting is the warning printed on the first interrupt.
+var errInterrupting = newError(kindCancelled, "interrupted, finishing the files in progress (press Ctrl-C again to abort)")
+
+// interruptContext returns a context that is cancelled on the first SIGINT,
+// so commands stop starting new work and report what they did. A second
+// SIGINT removes the temporary files of unfinished copies and exits at once.
+// stop stops listening for the signal.
+func interruptContext() (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt)
+	go watchInterrupts(signals, cancel, func() {
+		helper.RemoveTempFiles()
+		os.Exit(exitCancelled)
+	})
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// watchInterrupts calls cancel on the first signal and abort on the second.
+func watchInterrupts(signals <-chan os.Signal, cancel func(), abort func()) {
+	if _, ok := <-signals; !ok {
+		return
+	}
+	printDiagnostic("warning", errInterrupting)
+	cancel()
+	if _, ok := <-signals; !ok {
+		return
+	}
+	abort()
+}
+
+// commandContext returns the context cmd was run with. Commands built without
+// one, as in tests, get a context that is never cancelled.
+func commandContext(cmd *cobra.Command) context.Context {
+	if ctx := cmd.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// TestWatchInterrupts checks that the first interrupt cancels and the second aborts.
+func TestWatchInterrupts(t *testing.T) {
+	signals := make(chan os.Signal)
+	cancelled := make(chan bool, 1)
+	aborted := make(chan bool, 1)
+	done := make(chan bool)
+	go func() {
+		watchInterrupts(signals, func() { cancelled <- true }, func() { aborted <- true })
+		close(done)
+	}()
+
+	out := captureStderr(func() {
+		signals <- os.Interrupt
+		<-cancelled
+	})
+	if !contains(out, "press Ctrl-C again to abort") {
+		t.Fatalf("expected a warning about the interrupt, got: %q", out)
+	}
+	select {
+	case <-aborted:
+		t.Fatalf("expected the first interrupt not to abort")
+	default:
+	}
+	signals <- os.Interrupt
+	<-aborted
+	<-done
+}
+
+// TestCommandContext checks that commands run without a context get one.
+func TestCommandContext(t *testing.T) {
+	if ctx := commandContext(&cobra.Command{}); ctx == nil || ctx.Err() != nil {
+		t.Fatalf("expected a live context, got %v", ctx)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cmd := &cobra.Command{}
+	cmd.SetContext(ctx)
+	if commandContext(cmd).Err() == nil {
+		t.Fatalf("expected the command's own context")
+	}
+}
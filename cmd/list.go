@@ -4,53 +4,68 @@ import (
 	"f/helper"
 	"fmt"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 )
 
-func runList(cmd *cobra.Command, args []string) {
+func runList(cmd *cobra.Command, args []string) error {
 	// Check if the no-directory-sizes flag is set
 	noDirSizes, err := cmd.Flags().GetBool("no-directory-sizes")
 	if err != nil {
-		fmt.Println("Error getting flag value:", err)
-		return
+		return err
 	}
 	dir_sizes := !noDirSizes
 
 	// Check if the tree flag is set
 	isTree, err := cmd.Flags().GetBool("tree")
 	if err != nil {
-		fmt.Println("Error getting flag value:", err)
-		return
+		return err
 	}
 
 	// Check if the hidden flag is set
 	includeHidden, err := cmd.Flags().GetBool("hidden")
 	if err != nil {
-		fmt.Println("Error getting flag value:", err)
-		return
+		return err
+	}
+
+	// Check whether ignore files should be honored in tree mode
+	useIgnore, err := respectIgnore(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Check if the git flag is set
+	showGit, err := cmd.Flags().GetBool("git")
+	if err != nil {
+		return err
 	}
 
 	// Read the files from the directory
 	dir, err := helper.GetDirectoryFromArgs(args, 1)
 	if err != nil {
-		fmt.Println("Error getting directory:", err)
-		return
+		return err
+	}
+
+	var gitStatus *helper.GitStatus
+	if showGit {
+		gitStatus, err = helper.LoadGitStatus(dir)
+		if err != nil {
+			return fmt.Errorf("reading git status: %w", err)
+		}
 	}
 
 	files := []helper.Entry{}
 	if isTree {
-		files, err = helper.GetDirectoryTree(dir, includeHidden)
-		if err != nil {
-			fmt.Println("Error reading the directory:", err)
-			return
+		files, err = helper.GetDirectoryTree(commandContext(cmd), dir, includeHidden, useIgnore)
+		if err := handleWalkError(err); err != nil {
+			return err
 		}
 	} else {
 		files, err = helper.GetFileListing(dir, includeHidden)
 		if err != nil {
-			fmt.Println("Error reading the directory:", err)
-			return
+			return err
 		}
 	}
 
@@ -63,27 +78,36 @@ func runList(cmd *cobra.Command, args []string) {
 
 	formatStr := ""
 	if isTree {
-		formatStr = fmt.Sprintf("%%-10s %%-10s %%-30s\n")
+		formatStr = fmt.Sprintf("%%-10s %%-10s %%-30s %%s\n")
 	} else {
-		formatStr = fmt.Sprintf("%%-%ds %%-10s %%-10s %%-30s\n", longestFileName)
+		formatStr = fmt.Sprintf("%%-%ds %%-10s %%-10s %%-30s %%s\n", longestFileName)
 	}
 	dataFormatStr := ""
 	if isTree {
-		dataFormatStr = fmt.Sprintf("%%-10s %%-10s %%-30s %%s\n")
+		dataFormatStr = fmt.Sprintf("%%-10s %%-10s %%-30s %%s%%s\n")
 	} else {
-		dataFormatStr = fmt.Sprintf("%%-%ds %%-10s %%-10s %%-30s\n", longestFileName)
+		// The name is padded by hand so escape codes don't throw off the column width
+		dataFormatStr = "%s %-10s %-10s %-30s %s\n"
+	}
+
+	// The git column is only present when requested
+	gitHeader := ""
+	if showGit {
+		gitHeader = "Git"
 	}
 
 	// Display metadata for each file
 	if isTree {
-		fmt.Printf(formatStr, "Size", "Type", "Modified")
+		fmt.Printf(formatStr, "Size", "Type", "Modified", gitHeader)
 	} else {
-		fmt.Printf(formatStr, "Name", "Size", "Type", "Modified")
+		fmt.Printf(formatStr, "Name", "Size", "Type", "Modified", gitHeader)
 	}
 	for _, file := range files {
 		fileInfo, err := file.DirEntry.Info()
 		if err != nil {
-			fmt.Println("Error getting file info:", err)
+			// The entry may have been removed since the directory was read
+			printDiagnostic("warning", err)
+			exitCode = max(exitCode, exitPartial)
 			continue
 		}
 
@@ -97,34 +121,50 @@ func runList(cmd *cobra.Command, args []string) {
 				if err != nil {
 					fileSize = "Unknown"
 				} else {
-					fileSize = helper.FormatSize(dirSize)
+					fileSize = formatSize(dirSize)
 				}
 			} else {
 				fileSize = "N/A"
 			}
 		} else {
 			fileType = "File"
-			fileSize = helper.FormatSize(fileInfo.Size())
+			fileSize = formatSize(fileInfo.Size())
+		}
+
+		// Get git state, rolled up from children for directories
+		gitState := ""
+		if gitStatus != nil {
+			gitState = gitStatus.State(file.FullPath, file.DirEntry.IsDir()).String()
 		}
 
 		// Displaying file metadata
+		name := file.DirEntry.Name()
+		styledName := style.FileName(name, fileInfo.Mode())
 		if isTree {
-			fmt.Printf(dataFormatStr, fileSize, fileType, fileInfo.ModTime().Format(time.RFC1123), file.Path)
+			prefix := strings.TrimSuffix(file.Path, name)
+			if gitState != "" {
+				gitState = fmt.Sprintf("%-10s ", gitState)
+			}
+			fmt.Printf(dataFormatStr, fileSize, fileType, fileInfo.ModTime().Format(time.RFC1123), gitState, prefix+styledName)
 		} else {
-			fmt.Printf(dataFormatStr, file.DirEntry.Name(), fileSize, fileType, fileInfo.ModTime().Format(time.RFC1123))
+			paddedName := styledName + strings.Repeat(" ", longestFileName-len(name))
+			fmt.Printf(dataFormatStr, paddedName, fileSize, fileType, fileInfo.ModTime().Format(time.RFC1123), gitState)
 		}
 	}
+	return nil
 }
 
 var listCmd = &cobra.Command{
 	Use:   "list [directory]",
 	Short: "List files in the specified directory",
 	Long:  `List files in the specified directory. If no directory is specified, the current directory is used.`,
-	Run:   runList,
+	RunE:  runList,
 }
 
 func init() {
 	listCmd.Flags().BoolP("no-directory-sizes", "n", false, "Do not display directory sizes")
 	listCmd.Flags().BoolP("tree", "t", false, "Display directory tree")
 	listCmd.Flags().BoolP("hidden", "a", false, "Include hidden files and directories")
+	addIgnoreFlags(listCmd, false)
+	listCmd.Flags().BoolP("git", "g", false, "Show the git working-tree state of each entry")
 }
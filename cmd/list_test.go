@@ -17,6 +17,8 @@ func TestRunList_EmptyDir_VerifiesHeader(t *testing.T) {
 	cmd.Flags().BoolP("no-directory-sizes", "n", false, "Do not display directory sizes")
 	cmd.Flags().BoolP("tree", "t", false, "Display directory tree")
 	cmd.Flags().BoolP("hidden", "a", false, "Include hidden files and directories")
+	addIgnoreFlags(cmd, false)
+	cmd.Flags().BoolP("git", "g", false, "Show git status")
 
 	out := captureOutput(func() {
 		runList(cmd, []string{td})
@@ -45,6 +47,8 @@ func TestRunList_TreeMode(t *testing.T) {
 	cmd.Flags().BoolP("no-directory-sizes", "n", false, "Do not display directory sizes")
 	cmd.Flags().BoolP("tree", "t", true, "Display directory tree")
 	cmd.Flags().BoolP("hidden", "a", false, "Include hidden files and directories")
+	addIgnoreFlags(cmd, false)
+	cmd.Flags().BoolP("git", "g", false, "Show git status")
 
 	out := captureOutput(func() {
 		runList(cmd, []string{td})
@@ -72,6 +76,8 @@ func TestRunList_HiddenFlag(t *testing.T) {
 	cmdNoHidden.Flags().BoolP("no-directory-sizes", "n", false, "Do not display directory sizes")
 	cmdNoHidden.Flags().BoolP("tree", "t", false, "Display directory tree")
 	cmdNoHidden.Flags().BoolP("hidden", "a", false, "Include hidden files and directories")
+	addIgnoreFlags(cmdNoHidden, false)
+	cmdNoHidden.Flags().BoolP("git", "g", false, "Show git status")
 
 	outNoHidden := captureOutput(func() {
 		runList(cmdNoHidden, []string{td})
@@ -87,6 +93,8 @@ func TestRunList_HiddenFlag(t *testing.T) {
 	cmdHidden.Flags().BoolP("tree", "t", false, "Display directory tree")
 	// set the hidden flag default to true so GetBool returns true
 	cmdHidden.Flags().BoolP("hidden", "a", true, "Include hidden files and directories")
+	addIgnoreFlags(cmdHidden, false)
+	cmdHidden.Flags().BoolP("git", "g", false, "Show git status")
 
 	outHidden := captureOutput(func() {
 		runList(cmdHidden, []string{td})
@@ -8,22 +8,20 @@ import (
 	"github.com/spf13/cobra"
 )
 
-// TestRunMove_Usage_NoArgs verifies that running the move command with no arguments displays the usage message.
+// TestRunMove_Usage_NoArgs verifies that running the move command with no arguments returns a usage error.
 func TestRunMove_Usage_NoArgs(t *testing.T) {
 	cmd := &cobra.Command{}
 	// must define the flag so GetBool won't error
 	cmd.Flags().BoolP("overwrite", "o", false, "Overwrite")
 
-	out := captureOutput(func() {
-		runMove(cmd, []string{})
-	})
+	err := runMove(cmd, []string{})
 
-	if !contains(out, "Usage: move") {
-		t.Fatalf("expected usage message, got: %q", out)
+	if err == nil || !contains(err.Error(), "usage: move") {
+		t.Fatalf("expected usage error, got: %v", err)
 	}
 }
 
-// TestRunMove_NoMatches verifies that running the move command with no matches displays the no-match message.
+// TestRunMove_NoMatches verifies that running the move command with no matches returns a not-found error.
 func TestRunMove_NoMatches(t *testing.T) {
 	td := t.TempDir()
 	dst := filepath.Join(td, "dst")
@@ -34,12 +32,10 @@ func TestRunMove_NoMatches(t *testing.T) {
 
 	pattern := filepath.Join(td, "no_such_*")
 
-	out := captureOutput(func() {
-		runMove(cmd, []string{pattern, dst})
-	})
+	err := runMove(cmd, []string{pattern, dst})
 
-	if !contains(out, "No files matched the source pattern") {
-		t.Fatalf("expected no-match message, got: %q", out)
+	if err == nil || !contains(err.Error(), "no files matched the source pattern") || exitCodeOf(err) != exitNotFound {
+		t.Fatalf("expected a not-found error, got: %v", err)
 	}
 }
 
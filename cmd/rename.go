@@ -1,54 +1,366 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"f/helper"
 	"fmt"
 	"os"
 	"path/filepath"
+	"unicode/utf8"
 
 	"github.com/spf13/cobra"
 )
 
-func runRename(cmd *cobra.Command, args []string) {
+func runRename(cmd *cobra.Command, args []string) error {
+	swap, err := cmd.Flags().GetBool("swap")
+	if err != nil {
+		return err
+	}
+	if swap {
+		return runSwap(cmd, args)
+	}
+	edit, err := cmd.Flags().GetBool("edit")
+	if err != nil {
+		return err
+	}
+	if edit {
+		return runEditRename(cmd, args)
+	}
+	if cmd.Flags().Changed("template") {
+		return runTemplateRename(cmd, args)
+	}
+	if len(args) >= 2 && helper.IsSubstitution(args[0]) {
+		// A file that happens to look like s/a/b/ is still renamed normally
+		if _, err := os.Lstat(args[0]); err != nil {
+			return runSubstitutionRename(cmd, args)
+		}
+	}
 	if len(args) != 2 {
-		fmt.Println("Usage: rename <source> <newname>")
-		return
+		return errors.New("usage: rename <source> <newname>")
 	}
 
 	overwrite, err := cmd.Flags().GetBool("overwrite")
 	if err != nil {
-		fmt.Printf("Error parsing overwrite flag: %v\n", err)
-		return
+		return err
+	}
+
+	allowMove, err := cmd.Flags().GetBool("allow-move")
+	if err != nil {
+		return err
 	}
 
 	src := args[0]
 	newName := args[1]
+	dst, err := helper.RenameTarget(src, newName, allowMove)
+	if err != nil {
+		return moveHint(err)
+	}
+	ops := []helper.RenameOp{{From: src, To: dst}}
+
+	if err := helper.CheckRenames(ops, overwrite); err != nil {
+		return fmt.Errorf("checking destination file: %w", err)
+	}
+
+	var results batch
+	helper.ApplyRenames(commandContext(cmd), ops, overwrite, false, func(op helper.RenameOp, err error) {
+		if errors.Is(err, context.Canceled) {
+			results.skip(1)
+		} else if err != nil {
+			results.add(&pathError{action: "renaming", path: src, to: newName, err: err})
+		} else {
+			results.add(nil)
+			fmt.Printf("Renamed %s to %s successfully\n", src, newName)
+		}
+	})
+	return results.err()
+}
+
+// moveHint adds how to allow moves to an error about a path separator in a new name.
+func moveHint(err error) error {
+	if errors.Is(err, helper.ErrPathSeparator) {
+		return fmt.Errorf("%w (use --allow-move to move files to another directory)", err)
+	}
+	return err
+}
+
+// expandSources expands the wildcards in patterns, keeping paths that exist
+// literally, and drops duplicates.
+func expandSources(patterns []string) ([]string, error) {
+	seen := map[string]bool{}
+	var paths []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, &pathError{action: "expanding", path: pattern, err: err}
+		}
+		if len(matches) == 0 {
+			if _, err := os.Lstat(pattern); err != nil {
+				return nil, &pathError{action: "matching", path: pattern, err: errNoMatches}
+			}
+			matches = []string{pattern}
+		}
+		for _, match := range matches {
+			if !seen[filepath.Clean(match)] {
+				seen[filepath.Clean(match)] = true
+				paths = append(paths, match)
+			}
+		}
+	}
+	return paths, nil
+}
+
+// printRenameTable prints each rename as a before → after row. Deletions
+// have an empty To.
+func printRenameTable(ops []helper.RenameOp) {
+	width := 0
+	for _, op := range ops {
+		width = max(width, utf8.RuneCountInString(op.From))
+	}
+	for _, op := range ops {
+		padding := width - utf8.RuneCountInString(op.From)
+		to := op.To
+		if to == "" {
+			to = style.Failure("(delete)")
+		}
+		fmt.Printf("%s%*s → %s\n", op.From, padding, "", to)
+	}
+}
+
+// confirmRenames asks whether to go ahead with the renames shown in the table.
+func confirmRenames(count int) (bool, error) {
+	fmt.Printf("Rename %d files? (y/n): ", count)
+	input, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, fmt.Errorf("reading input: %w", err)
+	}
+	return input == "y\n" || input == "Y\n", nil
+}
+
+// runSubstitutionRename renames every file matched by args[1:] by applying the
+// substitution in args[0] to its name.
+func runSubstitutionRename(cmd *cobra.Command, args []string) error {
+	sub, err := helper.ParseSubstitution(args[0])
+	if err != nil {
+		return fmt.Errorf("parsing substitution: %w", err)
+	}
+	return runBulkRename(cmd, args[1:], func(path string, index int) (string, error) {
+		return sub.Apply(filepath.Base(path)), nil
+	})
+}
 
-	srcDir := filepath.Dir(src)
-	dst := filepath.Join(srcDir, newName)
+// runTemplateRename renames every file matched by args to the name built from
+// the --template flag.
+func runTemplateRename(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return errors.New("usage: rename --template <template> <source>...")
+	}
+	text, err := cmd.Flags().GetString("template")
+	if err != nil {
+		return err
+	}
+	start, err := cmd.Flags().GetInt("start")
+	if err != nil {
+		return err
+	}
+	template, err := helper.ParseRenameTemplate(text)
+	if err != nil {
+		return fmt.Errorf("parsing template: %w", err)
+	}
+	template.Start = start
+	return runBulkRename(cmd, args, template.Execute)
+}
 
-	if !overwrite {
-		if _, err := os.Stat(dst); !os.IsNotExist(err) {
-			err := fmt.Errorf("file already exists: %s", dst)
-			fmt.Printf("Error checking destination file: %v\n", err)
-			return
+// editorCommand returns the user's editor from $VISUAL or $EDITOR, falling back to vi.
+func editorCommand() ([]string, error) {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if editor := os.Getenv(name); editor != "" {
+			return splitCommand(editor)
 		}
 	}
+	return []string{"vi"}, nil
+}
 
-	err = os.Rename(src, dst)
+// editSources lists the paths to edit: the entries of a single directory
+// argument, or of the working directory without arguments, and otherwise the
+// files matched by the arguments.
+func editSources(args []string) ([]string, error) {
+	dir := "."
+	if len(args) == 1 {
+		if info, err := os.Stat(args[0]); err == nil && info.IsDir() {
+			dir = args[0]
+		} else {
+			return expandSources(args)
+		}
+	} else if len(args) > 1 {
+		return expandSources(args)
+	}
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		fmt.Printf("Error renaming %s to %s: %v\n", src, newName, err)
-	} else {
-		fmt.Printf("Renamed %s to %s successfully\n", src, newName)
+		return nil, err
 	}
+	paths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		paths = append(paths, filepath.Join(dir, entry.Name()))
+	}
+	return paths, nil
+}
+
+// runEditRename writes the paths to rename to a temporary file, opens it in the
+// user's editor and renames, moves or deletes files to match the edited list.
+func runEditRename(cmd *cobra.Command, args []string) error {
+	deleteRemoved, err := cmd.Flags().GetBool("delete")
+	if err != nil {
+		return err
+	}
+	paths, err := editSources(args)
+	if err != nil {
+		return fmt.Errorf("listing files: %w", err)
+	}
+	if len(paths) == 0 {
+		fmt.Println("No files to rename")
+		return nil
+	}
+	list, err := helper.FormatEditList(paths)
+	if err != nil {
+		return fmt.Errorf("listing files: %w", err)
+	}
+	editor, err := editorCommand()
+	if err != nil {
+		return fmt.Errorf("parsing editor command: %w", err)
+	}
+
+	file, err := os.CreateTemp("", "f-rename-*.txt")
+	if err != nil {
+		return fmt.Errorf("creating temporary file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(list)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("writing temporary file: %w", err)
+	}
+	if err := runCommand(append(editor, file.Name())); err != nil {
+		return fmt.Errorf("running editor: %w", err)
+	}
+	edited, err := os.ReadFile(file.Name())
+	if err != nil {
+		return fmt.Errorf("reading edited file: %w", err)
+	}
+
+	ops, removed, err := helper.ParseEditList(string(edited), paths)
+	if err != nil {
+		return fmt.Errorf("parsing edited file: %w", err)
+	}
+	if len(removed) > 0 && !deleteRemoved {
+		fmt.Printf("Leaving %d removed lines alone; use --delete to delete them\n", len(removed))
+		removed = nil
+	}
+	for _, path := range removed {
+		ops = append(ops, helper.RenameOp{From: path})
+	}
+	return applyBulkRename(cmd, ops, true)
+}
+
+// runBulkRename renames every file matched by patterns to the name returned by
+// newName, which gets each path and its position in the batch.
+func runBulkRename(cmd *cobra.Command, patterns []string, newName func(path string, index int) (string, error)) error {
+	paths, err := expandSources(patterns)
+	if err != nil {
+		return err
+	}
+	allowMove, err := cmd.Flags().GetBool("allow-move")
+	if err != nil {
+		return err
+	}
+	ops, err := helper.PlanRenames(paths, allowMove, newName)
+	if err != nil {
+		return fmt.Errorf("planning renames: %w", moveHint(err))
+	}
+	return applyBulkRename(cmd, ops, false)
+}
+
+// applyBulkRename shows ops as a table, checks them for collisions and, once
+// confirmed, carries them out. Missing target directories are only made with
+// createDirs, as for moves typed into the editor.
+func applyBulkRename(cmd *cobra.Command, ops []helper.RenameOp, createDirs bool) error {
+	overwrite, err := cmd.Flags().GetBool("overwrite")
+	if err != nil {
+		return err
+	}
+	force, err := cmd.Flags().GetBool("force")
+	if err != nil {
+		return err
+	}
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return err
+	}
+	if len(ops) == 0 {
+		fmt.Println("No files would be renamed")
+		return nil
+	}
+
+	printRenameTable(ops)
+	// Nothing is renamed unless the whole batch is safe
+	if err := helper.CheckRenames(ops, overwrite); err != nil {
+		return err
+	}
+	if dryRun {
+		return nil
+	}
+	if !force {
+		confirmed, err := confirmRenames(len(ops))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return errCancelled
+		}
+	}
+	var results batch
+	helper.ApplyRenames(commandContext(cmd), ops, overwrite, createDirs, func(op helper.RenameOp, err error) {
+		switch {
+		case errors.Is(err, context.Canceled):
+			results.skip(1)
+		case op.To == "" && err != nil:
+			results.add(&pathError{action: "deleting", path: op.From, err: err})
+		case op.To == "":
+			results.add(nil)
+			fmt.Println(style.Success(fmt.Sprintf("Deleted %s successfully", op.From)))
+		case err != nil:
+			results.add(&pathError{action: "renaming", path: op.From, to: op.To, err: err})
+		default:
+			results.add(nil)
+			fmt.Println(style.Success(fmt.Sprintf("Renamed %s to %s successfully", op.From, op.To)))
+		}
+	})
+	return results.err()
 }
 
 var renameCmd = &cobra.Command{
-	Use:   "rename <source> <newname>",
+	Use:   "rename <source> <newname> | rename --swap <first> <second> | rename s/<pattern>/<replacement>/ <source>... | rename --template <template> <source>... | rename --edit [directory|source...]",
 	Short: "Rename a file or directory",
-	Long:  `Rename a file or directory to a new name within the same directory.`,
-	Run:   runRename,
+	Long: `Rename a file or directory to a new name within the same directory. With --allow-move, the new name may be a path relative to the source's directory.
+With --swap, exchange the names of two files or directories atomically.
+With a s/pattern/replacement/ expression, rename every matched source by applying the regular expression substitution to its name.
+With --template, rename every matched source to a name built from placeholders such as {name}, {ext}, {n:03} and {date:2006-01-02}.
+With --edit, edit the list of names in $EDITOR; changed lines are renamed or moved, and removed lines are deleted with --delete.
+The renames are shown as a table and checked for collisions before anything is renamed.`,
+	RunE: runRename,
 }
 
 func init() {
 	renameCmd.Flags().BoolP("overwrite", "o", false, "Overwrite the destination file if it exists.")
+	renameCmd.Flags().BoolP("force", "f", false, "Rename without asking for confirmation.")
+	renameCmd.Flags().BoolP("dry-run", "n", false, "Only show what would be renamed.")
+	renameCmd.Flags().StringP("template", "t", "", "Build new names from placeholders: {name}, {ext}, {n:03}, {date:layout}, {parent}, {size}, {hash:8}, with filters like {name|lower}.")
+	renameCmd.Flags().Int("start", 1, "First sequence number for {n} in --template.")
+	renameCmd.Flags().BoolP("edit", "e", false, "Edit the names of the files in a directory or matched by the arguments in $EDITOR.")
+	renameCmd.Flags().Bool("allow-move", false, "Allow new names containing a path separator, moving files to another directory.")
+	renameCmd.Flags().Bool("swap", false, "Exchange the names of two files or directories.")
+	renameCmd.Flags().Bool("delete", false, "Delete files whose lines were removed in --edit.")
 }
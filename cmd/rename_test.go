@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"testing"
@@ -8,18 +10,19 @@ import (
 	"github.com/spf13/cobra"
 )
 
-// TestRunRename_Usage_NoArgs verifies that running the rename command with no arguments displays the usage message.
+// TestRunRename_Usage_NoArgs verifies that running the rename command with no arguments returns a usage error.
 func TestRunRename_Usage_NoArgs(t *testing.T) {
 	cmd := &cobra.Command{}
 	// define the flag so GetBool won't error
 	cmd.Flags().BoolP("overwrite", "o", false, "Overwrite")
+	cmd.Flags().Bool("allow-move", false, "Allow move")
+	cmd.Flags().Bool("swap", false, "Swap")
+	cmd.Flags().BoolP("edit", "e", false, "Edit")
 
-	out := captureOutput(func() {
-		runRename(cmd, []string{})
-	})
+	err := runRename(cmd, []string{})
 
-	if !contains(out, "Usage: rename") {
-		t.Fatalf("expected usage message, got: %q", out)
+	if err == nil || !contains(err.Error(), "usage: rename") {
+		t.Fatalf("expected usage error, got: %v", err)
 	}
 }
 
@@ -34,6 +37,9 @@ func TestRunRename_Success(t *testing.T) {
 	cmd := &cobra.Command{}
 	// overwrite flag default false is fine since destination does not exist
 	cmd.Flags().BoolP("overwrite", "o", false, "Overwrite")
+	cmd.Flags().Bool("allow-move", false, "Allow move")
+	cmd.Flags().Bool("swap", false, "Swap")
+	cmd.Flags().BoolP("edit", "e", false, "Edit")
 
 	out := captureOutput(func() {
 		runRename(cmd, []string{src, "new.txt"})
@@ -68,13 +74,14 @@ func TestRunRename_DestinationExists_NoOverwrite(t *testing.T) {
 	cmd := &cobra.Command{}
 	// default overwrite=false
 	cmd.Flags().BoolP("overwrite", "o", false, "Overwrite")
+	cmd.Flags().Bool("allow-move", false, "Allow move")
+	cmd.Flags().Bool("swap", false, "Swap")
+	cmd.Flags().BoolP("edit", "e", false, "Edit")
 
-	out := captureOutput(func() {
-		runRename(cmd, []string{src, "b.txt"})
-	})
+	err := runRename(cmd, []string{src, "b.txt"})
 
-	if !contains(out, "Error checking destination file") {
-		t.Fatalf("expected error about existing destination, got: %q", out)
+	if err == nil || !contains(err.Error(), "checking destination file") || exitCodeOf(err) != exitExists {
+		t.Fatalf("expected an exists error about the destination, got: %v", err)
 	}
 
 	// Ensure files are unchanged
@@ -102,6 +109,9 @@ func TestRunRename_DestinationExists_OverwriteTrue(t *testing.T) {
 	cmd := &cobra.Command{}
 	// set overwrite true so rename proceeds even if destination exists
 	cmd.Flags().BoolP("overwrite", "o", true, "Overwrite")
+	cmd.Flags().Bool("allow-move", false, "Allow move")
+	cmd.Flags().Bool("swap", false, "Swap")
+	cmd.Flags().BoolP("edit", "e", false, "Edit")
 
 	out := captureOutput(func() {
 		runRename(cmd, []string{src, "y.txt"})
@@ -125,3 +135,195 @@ func TestRunRename_DestinationExists_OverwriteTrue(t *testing.T) {
 		t.Fatalf("destination content mismatch: got %q want %q", string(b), "from-src")
 	}
 }
+
+// newRenameTestCmd returns a command with the rename flags defined.
+func newRenameTestCmd() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Flags().BoolP("overwrite", "o", false, "Overwrite")
+	cmd.Flags().Bool("allow-move", false, "Allow move")
+	cmd.Flags().Bool("swap", false, "Swap")
+	cmd.Flags().BoolP("edit", "e", false, "Edit")
+	cmd.Flags().BoolP("force", "f", true, "Force")
+	cmd.Flags().BoolP("dry-run", "n", false, "Dry run")
+	return cmd
+}
+
+// TestRunRename_BulkSubstitution verifies that every matched file is renamed with the substitution.
+func TestRunRename_BulkSubstitution(t *testing.T) {
+	td := t.TempDir()
+	for _, name := range []string{"IMG_001.jpg", "IMG_002.jpg", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(td, name), []byte(name), 0o644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	out := captureOutput(func() {
+		runRename(newRenameTestCmd(), []string{`s/IMG_(\d+)/photo-$1/`, filepath.Join(td, "*.jpg")})
+	})
+
+	if !contains(out, "IMG_001.jpg → "+filepath.Join(td, "photo-001.jpg")) {
+		t.Fatalf("expected a before → after table, got: %q", out)
+	}
+	for _, name := range []string{"photo-001.jpg", "photo-002.jpg", "notes.txt"} {
+		if _, err := os.Stat(filepath.Join(td, name)); err != nil {
+			t.Fatalf("expected %s to exist: %v", name, err)
+		}
+	}
+}
+
+// TestRunRename_BulkCollision verifies that nothing is renamed when two files would get the same name.
+func TestRunRename_BulkCollision(t *testing.T) {
+	td := t.TempDir()
+	for _, name := range []string{"a-1.txt", "a-2.txt"} {
+		if err := os.WriteFile(filepath.Join(td, name), []byte(name), 0o644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	var err error
+	captureOutput(func() {
+		err = runRename(newRenameTestCmd(), []string{`s/-\d//`, filepath.Join(td, "a-*.txt")})
+	})
+
+	if err == nil || !contains(err.Error(), "would both be renamed") || exitCodeOf(err) != exitExists {
+		t.Fatalf("expected a collision error, got: %v", err)
+	}
+	for _, name := range []string{"a-1.txt", "a-2.txt"} {
+		if _, err := os.Stat(filepath.Join(td, name)); err != nil {
+			t.Fatalf("expected %s to be left alone: %v", name, err)
+		}
+	}
+}
+
+// TestRunRename_Template verifies --template numbering and filters.
+func TestRunRename_Template(t *testing.T) {
+	td := t.TempDir()
+	for _, name := range []string{"Alpha Shot.png", "Beta Shot.png"} {
+		if err := os.WriteFile(filepath.Join(td, name), []byte(name), 0o644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	cmd := newRenameTestCmd()
+	cmd.Flags().StringP("template", "t", "", "Template")
+	cmd.Flags().Int("start", 1, "Start")
+	cmd.Flags().Set("template", "{n:02}-{name|snake}{ext}")
+	cmd.Flags().Set("start", "7")
+	captureOutput(func() {
+		runRename(cmd, []string{filepath.Join(td, "*.png")})
+	})
+
+	for _, name := range []string{"07-alpha_shot.png", "08-beta_shot.png"} {
+		if _, err := os.Stat(filepath.Join(td, name)); err != nil {
+			t.Fatalf("expected %s to exist: %v", name, err)
+		}
+	}
+}
+
+// TestRunRename_Edit verifies that renames made in the editor are applied, including a swap.
+func TestRunRename_Edit(t *testing.T) {
+	td := t.TempDir()
+	for _, name := range []string{"first.txt", "second.txt", "old.txt"} {
+		if err := os.WriteFile(filepath.Join(td, name), []byte(name), 0o644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+	// The "editor" swaps first and second, moves old.txt into a new directory
+	script := filepath.Join(t.TempDir(), "editor.sh")
+	edit := `sed -i -e 's#/first\.txt$#/TMP#' -e 's#/second\.txt$#/first.txt#' -e 's#/TMP$#/second.txt#' -e 's#/old\.txt$#/archive/old.txt#' "$1"`
+	if err := os.WriteFile(script, []byte(edit), 0o755); err != nil {
+		t.Fatalf("failed to write editor script: %v", err)
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "sh "+script)
+
+	cmd := newRenameTestCmd()
+	cmd.Flags().Bool("delete", false, "Delete")
+	cmd.Flags().Set("edit", "true")
+	out := captureOutput(func() {
+		runRename(cmd, []string{td})
+	})
+
+	for name, want := range map[string]string{"first.txt": "second.txt", "second.txt": "first.txt", "archive/old.txt": "old.txt"} {
+		data, err := os.ReadFile(filepath.Join(td, name))
+		if err != nil || string(data) != want {
+			t.Fatalf("%s holds %q (%v), want %q; output: %q", name, data, err, want, out)
+		}
+	}
+}
+
+// TestRunRename_AllowMove verifies that new names with a path separator need --allow-move.
+func TestRunRename_AllowMove(t *testing.T) {
+	td := t.TempDir()
+	src := filepath.Join(td, "a.txt")
+	if err := os.WriteFile(src, []byte("A"), 0o644); err != nil {
+		t.Fatalf("failed to write source file: %v", err)
+	}
+
+	cmd := newRenameTestCmd()
+	if err := runRename(cmd, []string{src, "sub/b.txt"}); err == nil || !contains(err.Error(), "--allow-move") {
+		t.Fatalf("expected a hint about --allow-move, got: %v", err)
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Fatalf("expected source still to exist: %v", err)
+	}
+
+	if err := cmd.Flags().Set("allow-move", "true"); err != nil {
+		t.Fatalf("failed to set allow-move: %v", err)
+	}
+	// Only --edit makes missing directories
+	if err := runRename(cmd, []string{src, "sub/b.txt"}); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected the move into a missing directory to fail, got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(td, "sub")); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected no directory to be made: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(td, "sub"), 0o755); err != nil {
+		t.Fatalf("failed to create sub: %v", err)
+	}
+	out := captureOutput(func() {
+		runRename(cmd, []string{src, "sub/b.txt"})
+	})
+	if !contains(out, "Renamed") {
+		t.Fatalf("expected Renamed message, got: %q", out)
+	}
+	if data, err := os.ReadFile(filepath.Join(td, "sub", "b.txt")); err != nil || string(data) != "A" {
+		t.Fatalf("expected the file to be moved into sub: %q, %v", data, err)
+	}
+}
+
+// TestRunRename_Swap verifies that --swap exchanges two file names.
+func TestRunRename_Swap(t *testing.T) {
+	td := t.TempDir()
+	a, b := filepath.Join(td, "a.txt"), filepath.Join(td, "b.txt")
+	for path, text := range map[string]string{a: "A", b: "B"} {
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	cmd := newRenameTestCmd()
+	if err := cmd.Flags().Set("swap", "true"); err != nil {
+		t.Fatalf("failed to set swap: %v", err)
+	}
+	out := captureOutput(func() {
+		runRename(cmd, []string{a, b})
+	})
+	if !contains(out, "Swapped") {
+		t.Fatalf("expected Swapped message, got: %q", out)
+	}
+	if data, _ := os.ReadFile(a); string(data) != "B" {
+		t.Fatalf("expected a.txt to hold B, got %q", data)
+	}
+
+	// --swap=false renames as usual, so the second path is taken
+	if err := cmd.Flags().Set("swap", "false"); err != nil {
+		t.Fatalf("failed to set swap: %v", err)
+	}
+	if err := runRename(cmd, []string{a, "b.txt"}); err == nil {
+		t.Fatalf("expected a plain rename onto b.txt to fail")
+	}
+	if data, _ := os.ReadFile(a); string(data) != "B" {
+		t.Fatalf("expected a.txt to be left alone, got %q", data)
+	}
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"f/helper"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// printDiff prints a unified diff with removed lines in red and added lines in green.
+func printDiff(diff string) {
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			fmt.Print(line)
+		case strings.HasPrefix(line, "-"):
+			fmt.Print(style.Failure(strings.TrimSuffix(line, "\n")), "\n")
+		case strings.HasPrefix(line, "+"):
+			fmt.Print(style.Success(strings.TrimSuffix(line, "\n")), "\n")
+		default:
+			fmt.Print(line)
+		}
+	}
+}
+
+// confirmHunks shows each hunk of edit and asks whether to apply it. It returns
+// the selection and whether the user asked to stop.
+func confirmHunks(edit *helper.FileEdit, reader *bufio.Reader) ([]bool, bool, error) {
+	selected := make([]bool, len(edit.Hunks))
+	fmt.Printf("--- a/%s\n+++ b/%s\n", edit.Path, edit.Path)
+	for i := range edit.Hunks {
+		printDiff(edit.HunkDiff(i, selected))
+		fmt.Printf("Apply this change (%d/%d) to %s? (y/n/a/d/q): ", i+1, len(edit.Hunks), edit.Path)
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, true, fmt.Errorf("reading input: %w", err)
+		}
+		switch strings.TrimSpace(input) {
+		case "y", "Y":
+			selected[i] = true
+		case "a", "A":
+			// Apply this and the remaining hunks in the file
+			for j := i; j < len(selected); j++ {
+				selected[j] = true
+			}
+			return selected, false, nil
+		case "d", "D":
+			// Skip the remaining hunks in the file
+			return selected, false, nil
+		case "q", "Q":
+			return selected, true, nil
+		}
+	}
+	return selected, false, nil
+}
+
+func runReplace(cmd *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		return errors.New("not enough arguments")
+	}
+	opts, err := walkOptions(cmd)
+	if err != nil {
+		return err
+	}
+	if opts.Match.Regex, err = cmd.Flags().GetBool("regex"); err != nil {
+		return err
+	}
+	if opts.Match.IgnoreCase, err = cmd.Flags().GetBool("ignore-case"); err != nil {
+		return err
+	}
+	preview, err := cmd.Flags().GetBool("preview")
+	if err != nil {
+		return err
+	}
+	interactive, err := cmd.Flags().GetBool("interactive")
+	if err != nil {
+		return err
+	}
+
+	replacer, err := helper.NewReplacer(args[0], args[1], opts.Match)
+	if err != nil {
+		return err
+	}
+	dir, err := helper.GetDirectoryFromArgs(args, 3)
+	if err != nil {
+		return err
+	}
+	edits, err := helper.PlanReplace(commandContext(cmd), dir, replacer, opts)
+	if err := handleWalkError(err); err != nil {
+		return err
+	}
+	if len(edits) == 0 {
+		return errNoResults
+	}
+
+	if preview {
+		for _, edit := range edits {
+			printDiff(edit.Diff(nil))
+		}
+		return nil
+	}
+
+	ctx := commandContext(cmd)
+	reader := bufio.NewReader(os.Stdin)
+	files, matches := 0, 0
+	var results batch
+	for i, edit := range edits {
+		if ctx.Err() != nil {
+			results.skip(len(edits) - i)
+			break
+		}
+		var selected []bool
+		stop := false
+		if interactive {
+			if selected, stop, err = confirmHunks(edit, reader); err != nil {
+				return err
+			}
+		}
+		if count := edit.Matches(selected); count > 0 {
+			if err := edit.Apply(selected); err != nil {
+				results.add(&pathError{action: "replacing in", path: edit.Path, err: err})
+			} else {
+				results.add(nil)
+				files++
+				matches += count
+			}
+		}
+		if stop {
+			break
+		}
+	}
+	fmt.Println(style.Success(fmt.Sprintf("Replaced %d occurrences in %d files", matches, files)))
+	return results.err()
+}
+
+var replaceCmd = &cobra.Command{
+	Use:   "replace <pattern> <replacement> [directory]",
+	Short: "Replace text in files",
+	Long: `Replace every match of a pattern in the files below a directory, line by line.
+If no directory is specified, the current directory is used. With --regex, the replacement can refer to capture groups as $1 or ${name}.`,
+	RunE: runReplace,
+}
+
+func init() {
+	addIgnoreFlags(replaceCmd, true)
+	addWalkFlags(replaceCmd)
+	replaceCmd.Flags().BoolP("regex", "r", false, "Treat the pattern as a regular expression (RE2 syntax)")
+	replaceCmd.Flags().BoolP("ignore-case", "i", false, "Match regardless of case")
+	replaceCmd.Flags().Bool("preview", false, "Show the changes as a unified diff without writing them")
+	replaceCmd.Flags().BoolP("interactive", "I", false, "Ask before applying each change")
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// newReplaceTestCmd returns a command with the replace flags defined.
+func newReplaceTestCmd() *cobra.Command {
+	cmd := &cobra.Command{}
+	addIgnoreFlags(cmd, true)
+	addWalkFlags(cmd)
+	cmd.Flags().BoolP("regex", "r", false, "Regex")
+	cmd.Flags().BoolP("ignore-case", "i", false, "Ignore case")
+	cmd.Flags().Bool("preview", false, "Preview")
+	cmd.Flags().BoolP("interactive", "I", false, "Interactive")
+	return cmd
+}
+
+// writeReplaceFixture creates a file with the given content in a new directory.
+func writeReplaceFixture(t *testing.T, content string) (string, string) {
+	t.Helper()
+	td := t.TempDir()
+	path := filepath.Join(td, "config.txt")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	return td, path
+}
+
+// TestRunReplace_PreviewAndApply verifies --preview leaves the file alone and a
+// regex replacement with capture groups is written.
+func TestRunReplace_PreviewAndApply(t *testing.T) {
+	content := "host=alpha\nport=80\n"
+	td, path := writeReplaceFixture(t, content)
+
+	cmd := newReplaceTestCmd()
+	cmd.Flags().Set("regex", "true")
+	cmd.Flags().Set("preview", "true")
+	out := captureOutput(func() {
+		if err := runReplace(cmd, []string{`(\w+)=(\w+)`, "$1: $2", td}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !contains(out, "-host=alpha") || !contains(out, "+host: alpha") || !contains(out, "@@ -1,2 +1,2 @@") {
+		t.Fatalf("expected a unified diff; got: %q", out)
+	}
+	if data, _ := os.ReadFile(path); string(data) != content {
+		t.Fatalf("--preview should not change the file, got %q", data)
+	}
+
+	cmd = newReplaceTestCmd()
+	cmd.Flags().Set("regex", "true")
+	out = captureOutput(func() {
+		if err := runReplace(cmd, []string{`(\w+)=(\w+)`, "$1: $2", td}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !contains(out, "Replaced 2 occurrences in 1 files") {
+		t.Fatalf("expected a summary; got: %q", out)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "host: alpha\nport: 80\n" {
+		t.Fatalf("unexpected content %q", data)
+	}
+}
+
+// TestRunReplace_Interactive verifies per-hunk confirmation.
+func TestRunReplace_Interactive(t *testing.T) {
+	content := "old\n1\n2\n3\n4\n5\n6\n7\n8\nold\n"
+	td, path := writeReplaceFixture(t, content)
+
+	oldStdin := os.Stdin
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe for stdin: %v", err)
+	}
+	// Reject the first hunk and accept the second
+	go func() {
+		_, _ = w.Write([]byte("n\ny\n"))
+		_ = w.Close()
+	}()
+	os.Stdin = r
+	defer func() { os.Stdin = oldStdin }()
+
+	cmd := newReplaceTestCmd()
+	cmd.Flags().Set("interactive", "true")
+	out := captureOutput(func() {
+		if err := runReplace(cmd, []string{"old", "new", td}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !contains(out, "(1/2)") || !contains(out, "(2/2)") {
+		t.Fatalf("expected a prompt per hunk; got: %q", out)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "old\n1\n2\n3\n4\n5\n6\n7\n8\nnew\n" {
+		t.Fatalf("expected only the second hunk to be applied, got %q", data)
+	}
+}
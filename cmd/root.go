@@ -22,11 +22,92 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"f/helper"
+	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
 )
 
+// style is the shared output styler. It stays disabled until the root
+// command parses --color, so commands invoked directly never emit escape codes.
+var style = helper.NewStyler(helper.ColorNever, os.Stdout)
+
+// errStyle styles errors and warnings written to stderr.
+var errStyle = helper.NewStyler(helper.ColorNever, os.Stderr)
+
+// sizeUnits selects how sizes are printed. It is set from --units or F_UNITS.
+var sizeUnits = helper.UnitsIEC
+
+// jobs is how many paths copy, move and delete work on at once, or
+// helper.AutoJobs to pick the count automatically. It is set from --jobs or F_JOBS.
+var jobs = helper.AutoJobs
+
+// formatSize formats a size in bytes using the configured units.
+func formatSize(size int64) string {
+	return helper.FormatSizeUnits(size, sizeUnits)
+}
+
+// setupOutput configures the shared styler, size units and worker count from the global flags.
+func setupOutput(cmd *cobra.Command, args []string) error {
+	value, err := cmd.Flags().GetString("color")
+	if err != nil {
+		return err
+	}
+	mode, err := helper.ParseColorMode(value)
+	if err != nil {
+		return err
+	}
+	style = helper.NewStyler(mode, os.Stdout)
+	errStyle = helper.NewStyler(mode, os.Stderr)
+
+	// The flag takes precedence over the environment
+	units, err := cmd.Flags().GetString("units")
+	if err != nil {
+		return err
+	}
+	if !cmd.Flags().Changed("units") {
+		if env, ok := os.LookupEnv("F_UNITS"); ok {
+			units = env
+		}
+	}
+	sizeUnits, err = helper.ParseSizeUnits(units)
+	if err != nil {
+		return err
+	}
+
+	value, err = cmd.Flags().GetString("jobs")
+	if err != nil {
+		return err
+	}
+	if !cmd.Flags().Changed("jobs") {
+		if env, ok := os.LookupEnv("F_JOBS"); ok {
+			value = env
+		}
+	}
+	if jobs, err = helper.ParseJobs(value); err != nil {
+		return err
+	}
+
+	strictMode, err = cmd.Flags().GetBool("strict")
+	if err != nil {
+		return err
+	}
+
+	format, err := cmd.Flags().GetString("error-format")
+	if err != nil {
+		return err
+	}
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid error format %q (expected text or json)", format)
+	}
+	errorFormat = format
+
+	// Past flag parsing, a failing command doesn't need its usage shown
+	cmd.SilenceUsage = true
+	return nil
+}
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "f",
@@ -36,22 +117,36 @@ var rootCmd = &cobra.Command{
 	- copy <source> <destination>
 	- move <source> <destination>
 	- rename <source> <destination>
+	- rename --swap <first> <second>
+	- rename s/<pattern>/<replacement>/ <source>...
+	- rename --template <template> <source>...
+	- rename --edit [directory|source...]
+	- swap <first> <second>
 	- delete <source>
 	- list [directory]
-	- search <name|content> <query> [directory]
+	- du [directory]
+	- search <name|content|fuzzy> <query> [directory]
+	- find [directory]
+	- replace <pattern> <replacement> [directory]
 `,
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
+	PersistentPreRunE: setupOutput,
+	// Errors are printed by Execute, in the format chosen with --error-format
+	SilenceErrors: true,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	err := rootCmd.Execute()
+	ctx, stop := interruptContext()
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
 	if err != nil {
-		os.Exit(1)
+		os.Exit(reportCommandError(err))
 	}
+	os.Exit(exitCode)
 }
 
 func init() {
@@ -60,13 +155,22 @@ func init() {
 	// will be global for your application.
 
 	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.f.yaml)")
+	rootCmd.PersistentFlags().String("color", "auto", "When to use colors: auto, always or never")
+	rootCmd.PersistentFlags().String("error-format", "text", "How errors are printed to stderr: text or json")
+	rootCmd.PersistentFlags().String("jobs", "auto", "How many paths copy, move and delete work on at once: a number or auto (default from $F_JOBS); walking directories uses --threads instead")
+	rootCmd.PersistentFlags().Bool("strict", false, "Fail when any file or directory can't be read instead of printing a warning")
+	rootCmd.PersistentFlags().String("units", "iec", "Size units: iec (KiB, MiB, ...), si (kB, MB, ...) or bytes (default from $F_UNITS)")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
 	rootCmd.AddCommand(copyCmd)
 	rootCmd.AddCommand(moveCmd)
 	rootCmd.AddCommand(renameCmd)
+	rootCmd.AddCommand(swapCmd)
 	rootCmd.AddCommand(deleteCmd)
 	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(duCmd)
 	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(findCmd)
+	rootCmd.AddCommand(replaceCmd)
 }
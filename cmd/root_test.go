@@ -1,6 +1,11 @@
 package cmd
 
-import "testing"
+import (
+	"f/helper"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
 
 // TestRootCmd_Subcommands ensures rootCmd is configured with the expected subcommands.
 func TestRootCmd_Subcommands(t *testing.T) {
@@ -18,6 +23,7 @@ func TestRootCmd_Subcommands(t *testing.T) {
 		"rename": false,
 		"delete": false,
 		"list":   false,
+		"du":     false,
 		"search": false,
 	}
 
@@ -33,3 +39,66 @@ func TestRootCmd_Subcommands(t *testing.T) {
 		}
 	}
 }
+
+// newGlobalFlagsCmd returns a command with the global flags read by setupOutput.
+func newGlobalFlagsCmd() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Flags().String("color", "never", "Color")
+	cmd.Flags().String("units", "iec", "Units")
+	cmd.Flags().String("jobs", "auto", "Jobs")
+	cmd.Flags().Bool("strict", false, "Strict")
+	cmd.Flags().String("error-format", "text", "Error format")
+	return cmd
+}
+
+// TestSetupOutput_Units verifies that --units overrides F_UNITS and that F_UNITS is used otherwise.
+func TestSetupOutput_Units(t *testing.T) {
+	defer func() { sizeUnits = helper.UnitsIEC }()
+	t.Setenv("F_UNITS", "si")
+
+	if err := setupOutput(newGlobalFlagsCmd(), nil); err != nil {
+		t.Fatalf("setupOutput returned error: %v", err)
+	}
+	if got := formatSize(1000); got != "1.00 kB" {
+		t.Fatalf("expected F_UNITS=si to apply, got %q", got)
+	}
+
+	cmd := newGlobalFlagsCmd()
+	if err := cmd.Flags().Set("units", "bytes"); err != nil {
+		t.Fatalf("failed to set units flag: %v", err)
+	}
+	if err := setupOutput(cmd, nil); err != nil {
+		t.Fatalf("setupOutput returned error: %v", err)
+	}
+	if got := formatSize(1000); got != "1000" {
+		t.Fatalf("expected --units=bytes to override F_UNITS, got %q", got)
+	}
+}
+
+// TestSetupOutput_Jobs verifies that --jobs overrides F_JOBS, that F_JOBS is
+// used otherwise, and that invalid counts are rejected.
+func TestSetupOutput_Jobs(t *testing.T) {
+	defer func() { jobs = helper.AutoJobs }()
+
+	if err := setupOutput(newGlobalFlagsCmd(), nil); err != nil || jobs != helper.AutoJobs {
+		t.Fatalf("expected auto by default, got %d, %v", jobs, err)
+	}
+
+	t.Setenv("F_JOBS", "3")
+	if err := setupOutput(newGlobalFlagsCmd(), nil); err != nil || jobs != 3 {
+		t.Fatalf("expected F_JOBS=3 to apply, got %d, %v", jobs, err)
+	}
+
+	cmd := newGlobalFlagsCmd()
+	if err := cmd.Flags().Set("jobs", "8"); err != nil {
+		t.Fatalf("failed to set jobs flag: %v", err)
+	}
+	if err := setupOutput(cmd, nil); err != nil || jobs != 8 {
+		t.Fatalf("expected --jobs=8 to override F_JOBS, got %d, %v", jobs, err)
+	}
+
+	t.Setenv("F_JOBS", "0")
+	if err := setupOutput(newGlobalFlagsCmd(), nil); err == nil {
+		t.Fatalf("expected F_JOBS=0 to be rejected")
+	}
+}
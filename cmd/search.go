@@ -1,14 +1,25 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"f/helper"
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
-func runSearch(args []string, helperFunc func(string, string) ([]string, error)) error {
+// runSearch runs helperFunc with the query and directory from args and prints each result.
+// If format is non-nil it is called with each result and the directory to style the result before printing.
+func runSearch(args []string, helperFunc func(string, string) ([]string, error), format func(string, string) string) error {
+	return runSearchOutput(context.Background(), args, helperFunc, &resultOutput{}, format)
+}
+
+// runSearchOutput is runSearch with the results handed to output, which prints
+// them or runs an action on them.
+func runSearchOutput(ctx context.Context, args []string, helperFunc func(string, string) ([]string, error), output *resultOutput, format func(string, string) string) error {
 	if len(args) < 1 {
 		return errors.New("not enough arguments")
 	}
@@ -16,34 +27,348 @@ func runSearch(args []string, helperFunc func(string, string) ([]string, error))
 	// Read the files from the directory
 	dir, err := helper.GetDirectoryFromArgs(args, 2)
 	if err != nil {
-		fmt.Println("Error getting directory:", err)
 		return err
 	}
 	results, err := helperFunc(query, dir)
-	if err != nil {
+	if err := handleWalkError(err); err != nil {
 		return err
 	}
 	if len(results) == 0 {
-		return errors.New("no files found for search criteria")
+		return errNoResults
 	}
-	for _, result := range results {
-		fmt.Println(result)
+	return output.handle(ctx, results, dir, format)
+}
+
+// nameHighlighter returns a formatter that highlights the matched part of each result.
+func nameHighlighter(matcher helper.Matcher, opts helper.SearchOptions) func(string, string) string {
+	return func(path, dir string) string {
+		target := opts.NameTarget(path, dir)
+		if !strings.HasSuffix(filepath.ToSlash(path), target) {
+			return path
+		}
+		prefix := path[:len(path)-len(target)]
+		return prefix + style.Highlight(target, matcher.FindAll(target))
 	}
-	return nil
+}
+
+// walkOptions builds the helper search options that control the walk itself
+// from the flags defined by addIgnoreFlags and addWalkFlags.
+func walkOptions(cmd *cobra.Command) (helper.SearchOptions, error) {
+	opts := helper.SearchOptions{}
+	var err error
+	if opts.RespectIgnore, err = respectIgnore(cmd); err != nil {
+		return opts, err
+	}
+	if opts.Filter, err = fileFilter(cmd); err != nil {
+		return opts, err
+	}
+	if opts.Threads, err = cmd.Flags().GetInt("threads"); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// sortByPath resolves the --sort flag. Without it, results that are collected
+// before printing are sorted by path, while streamed ones print as found.
+func sortByPath(cmd *cobra.Command, streaming bool) (bool, error) {
+	sortBy, err := cmd.Flags().GetString("sort")
+	if err != nil {
+		return false, err
+	}
+	switch sortBy {
+	case "":
+		return !streaming, nil
+	case "none":
+		return false, nil
+	case "path":
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid sort order %q (expected none or path)", sortBy)
+}
+
+// searchOptions builds the helper search options shared by name and content search from the command's flags.
+func searchOptions(cmd *cobra.Command) (helper.SearchOptions, error) {
+	opts, err := walkOptions(cmd)
+	if err != nil {
+		return opts, err
+	}
+	if opts.Match.Regex, err = cmd.Flags().GetBool("regex"); err != nil {
+		return opts, err
+	}
+	if opts.Match.IgnoreCase, err = cmd.Flags().GetBool("ignore-case"); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// contentOptions adds the content-only flags to the search options.
+func contentOptions(cmd *cobra.Command) (helper.SearchOptions, error) {
+	opts, err := searchOptions(cmd)
+	if err != nil {
+		return opts, err
+	}
+	binary, err := cmd.Flags().GetString("binary")
+	if err != nil {
+		return opts, err
+	}
+	if opts.Binary, err = helper.ParseBinaryMode(binary); err != nil {
+		return opts, err
+	}
+	encoding, err := cmd.Flags().GetString("encoding")
+	if err != nil {
+		return opts, err
+	}
+	if opts.Encoding, err = helper.ParseEncoding(encoding); err != nil {
+		return opts, err
+	}
+
+	context, err := cmd.Flags().GetInt("context")
+	if err != nil {
+		return opts, err
+	}
+	if opts.After, err = cmd.Flags().GetInt("after-context"); err != nil {
+		return opts, err
+	}
+	if opts.Before, err = cmd.Flags().GetInt("before-context"); err != nil {
+		return opts, err
+	}
+	// -A and -B take precedence over -C, even when set to 0
+	if !cmd.Flags().Changed("after-context") {
+		opts.After = context
+	}
+	if !cmd.Flags().Changed("before-context") {
+		opts.Before = context
+	}
+	return opts, nil
 }
 
 func runNameSearch(cmd *cobra.Command, args []string) error {
-	return runSearch(args, helper.SearchByName)
+	opts, err := searchOptions(cmd)
+	if err != nil {
+		return err
+	}
+	if opts.SortByPath, err = sortByPath(cmd, false); err != nil {
+		return err
+	}
+	if opts.Match.Glob, err = cmd.Flags().GetBool("glob"); err != nil {
+		return err
+	}
+	if opts.Match.WholeName, err = cmd.Flags().GetBool("whole-name"); err != nil {
+		return err
+	}
+	if opts.FullPath, err = cmd.Flags().GetBool("full-path"); err != nil {
+		return err
+	}
+	output, err := resultOutputFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 {
+		return errors.New("not enough arguments")
+	}
+
+	matcher, err := helper.NewMatcher(args[0], opts.Match)
+	if err != nil {
+		return err
+	}
+	search := func(query, dir string) ([]string, error) {
+		return helper.SearchByName(commandContext(cmd), query, dir, opts)
+	}
+	return runSearchOutput(commandContext(cmd), args, search, output, nameHighlighter(matcher, opts))
+}
+
+// contentPrinter prints content search lines as they stream in, in grep's
+// path:line:col:text format. Context lines use path-line-text and
+// non-adjacent groups are separated by "--".
+type contentPrinter struct {
+	withContext bool
+	lastPath    string
+	lastNumber  int
+}
+
+func (p *contentPrinter) print(path string, line helper.ContentLine) error {
+	if p.withContext && p.lastPath != "" && (path != p.lastPath || line.Number > p.lastNumber+1) {
+		fmt.Println("--")
+	}
+	p.lastPath, p.lastNumber = path, line.Number
+	if line.Binary {
+		fmt.Printf("Binary file %s matches\n", path)
+		return nil
+	}
+	if line.IsMatch() {
+		fmt.Printf("%s:%d:%d:%s\n", path, line.Number, line.Column, style.Highlight(line.Text, line.Spans))
+	} else {
+		fmt.Printf("%s-%d-%s\n", path, line.Number, line.Text)
+	}
+	return nil
+}
+
+// contentCounter prints the number of matching lines per file. Files are
+// scanned one at a time, so a count is complete once the path changes.
+type contentCounter struct {
+	path  string
+	count int
+}
+
+func (c *contentCounter) add(path string, line helper.ContentLine) error {
+	if path != c.path {
+		c.flush()
+		c.path = path
+	}
+	c.count++
+	return nil
+}
+
+func (c *contentCounter) flush() {
+	if c.path != "" {
+		fmt.Printf("%s:%d\n", c.path, c.count)
+	}
+	c.path, c.count = "", 0
 }
 
 func runContentSearch(cmd *cobra.Command, args []string) error {
-	return runSearch(args, helper.SearchByContent)
+	opts, err := contentOptions(cmd)
+	if err != nil {
+		return err
+	}
+	filesOnly, err := cmd.Flags().GetBool("files-with-matches")
+	if err != nil {
+		return err
+	}
+	countOnly, err := cmd.Flags().GetBool("count")
+	if err != nil {
+		return err
+	}
+	output, err := resultOutputFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Lines are printed as they are found, while paths are collected first
+	streaming := !filesOnly && !output.pathsOnly()
+	if opts.SortByPath, err = sortByPath(cmd, streaming); err != nil {
+		return err
+	}
+
+	// Actions and NUL-separated output work on paths, so they imply -l
+	if !streaming {
+		search := func(query, dir string) ([]string, error) {
+			return helper.SearchByContent(commandContext(cmd), query, dir, opts)
+		}
+		return runSearchOutput(commandContext(cmd), args, search, output, nil)
+	}
+
+	if len(args) < 1 {
+		return errors.New("not enough arguments")
+	}
+	dir, err := helper.GetDirectoryFromArgs(args, 2)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	var handle func(string, helper.ContentLine) error
+	counter := &contentCounter{}
+	if countOnly {
+		opts.Before, opts.After = 0, 0
+		handle = counter.add
+	} else {
+		printer := &contentPrinter{withContext: opts.Before > 0 || opts.After > 0}
+		handle = printer.print
+	}
+	if opts.SortByPath {
+		// Sorting needs every result up front
+		var results []helper.ContentResult
+		results, err = helper.SearchContent(commandContext(cmd), args[0], dir, opts)
+		for _, result := range results {
+			for _, line := range result.Lines {
+				found = true
+				_ = handle(result.Path, line)
+			}
+		}
+	} else {
+		// Results are printed as they are found so large searches don't build up in memory
+		err = helper.StreamContent(commandContext(cmd), args[0], dir, opts, func(path string, line helper.ContentLine) error {
+			found = true
+			return handle(path, line)
+		})
+	}
+	counter.flush()
+	if err := handleWalkError(err); err != nil {
+		return err
+	}
+	if !found {
+		return errNoResults
+	}
+	return nil
+}
+
+func runFuzzySearch(cmd *cobra.Command, args []string) error {
+	// Results are always ranked, so there is no --sort
+	opts, err := walkOptions(cmd)
+	if err != nil {
+		return err
+	}
+	top, err := cmd.Flags().GetInt("top")
+	if err != nil {
+		return err
+	}
+	showScores, err := cmd.Flags().GetBool("scores")
+	if err != nil {
+		return err
+	}
+	output, err := resultOutputFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Keep the spans of each result so the printed paths can be highlighted
+	var matches map[string]helper.FuzzyMatch
+	search := func(query, dir string) ([]string, error) {
+		found, err := helper.FuzzySearch(commandContext(cmd), query, dir, opts, top)
+		matches = make(map[string]helper.FuzzyMatch, len(found))
+		results := make([]string, 0, len(found))
+		for _, match := range found {
+			matches[match.Path] = match
+			results = append(results, match.Path)
+		}
+		return results, err
+	}
+	format := func(path, dir string) string {
+		match := matches[path]
+		prefix := path[:len(path)-len(match.Target)]
+		result := prefix + style.Highlight(match.Target, match.Spans)
+		if showScores {
+			result = fmt.Sprintf("%5d %s", match.Score, result)
+		}
+		return result
+	}
+	return runSearchOutput(commandContext(cmd), args, search, output, format)
+}
+
+// addWalkFlags defines the worker and filter flags for commands that search directories.
+func addWalkFlags(cmd *cobra.Command) {
+	addThreadsFlag(cmd)
+	addFilterFlags(cmd)
+}
+
+// addSortFlag defines the --sort flag for commands that search concurrently.
+func addSortFlag(cmd *cobra.Command) {
+	cmd.Flags().String("sort", "", "Order results: none (as found) or path (default path, or none for content lines, which print as they are found)")
+}
+
+// addMatchFlags defines the query matching and walking flags shared by name and content search.
+func addMatchFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP("regex", "r", false, "Treat the query as a regular expression (RE2 syntax)")
+	cmd.Flags().BoolP("ignore-case", "i", false, "Match regardless of case")
+	addSortFlag(cmd)
+	addWalkFlags(cmd)
 }
 
 var searchCmd = &cobra.Command{
-	Use:   "search <name|content> <query> <directory>",
+	Use:   "search <name|content|fuzzy> <query> <directory>",
 	Short: "Search for files",
-	Long:  "Search for files by name or content",
+	Long:  "Search for files by name, content or approximate name",
 }
 
 var nameSearchCmd = &cobra.Command{
@@ -56,11 +381,41 @@ var nameSearchCmd = &cobra.Command{
 var contentSearchCmd = &cobra.Command{
 	Use:   "content <query> <directory>",
 	Short: "Search for files by content",
-	Long:  "Search for files by content",
+	Long:  "Search for files by content. Matching lines are printed as path:line:column:text.",
 	RunE:  runContentSearch,
 }
 
+var fuzzySearchCmd = &cobra.Command{
+	Use:   "fuzzy <query> <directory>",
+	Short: "Search for files by approximate name",
+	Long: `Search for files whose path relative to the directory contains the characters of the query in order.
+Results are ranked so consecutive characters and characters at the start of a word or in the file name score higher, and the best matches are printed first.`,
+	RunE: runFuzzySearch,
+}
+
 func init() {
+	addIgnoreFlags(nameSearchCmd, true)
+	addIgnoreFlags(contentSearchCmd, true)
+	addMatchFlags(nameSearchCmd)
+	addMatchFlags(contentSearchCmd)
+	addResultFlags(nameSearchCmd)
+	addResultFlags(contentSearchCmd)
+	nameSearchCmd.Flags().BoolP("glob", "g", false, "Treat the query as a glob pattern; ** matches any number of directories")
+	nameSearchCmd.Flags().BoolP("whole-name", "w", false, "Require the query to match the whole name")
+	nameSearchCmd.Flags().BoolP("full-path", "p", false, "Match against the path relative to the directory instead of the file name")
+	contentSearchCmd.Flags().IntP("context", "C", 0, "Show this many lines of context around each match")
+	contentSearchCmd.Flags().IntP("after-context", "A", 0, "Show this many lines of context after each match")
+	contentSearchCmd.Flags().IntP("before-context", "B", 0, "Show this many lines of context before each match")
+	contentSearchCmd.Flags().BoolP("count", "c", false, "Only print the number of matching lines in each file")
+	contentSearchCmd.Flags().BoolP("files-with-matches", "l", false, "Only print the paths of files with a match")
+	contentSearchCmd.Flags().String("binary", "skip", "How to treat binary files: skip, match-only or text")
+	contentSearchCmd.Flags().String("encoding", "auto", "Decode files from this encoding: auto, utf-8, latin1, utf-16le or utf-16be")
+	addIgnoreFlags(fuzzySearchCmd, true)
+	addWalkFlags(fuzzySearchCmd)
+	addResultFlags(fuzzySearchCmd)
+	fuzzySearchCmd.Flags().IntP("top", "n", 20, "Show only the N best matches (0 for all)")
+	fuzzySearchCmd.Flags().Bool("scores", false, "Print the score of each match")
 	searchCmd.AddCommand(nameSearchCmd)
 	searchCmd.AddCommand(contentSearchCmd)
+	searchCmd.AddCommand(fuzzySearchCmd)
 }
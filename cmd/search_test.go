@@ -10,9 +10,37 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// newNameSearchTestCmd returns a command with the name search flags defined.
+func newNameSearchTestCmd() *cobra.Command {
+	cmd := &cobra.Command{}
+	addIgnoreFlags(cmd, true)
+	addMatchFlags(cmd)
+	addResultFlags(cmd)
+	cmd.Flags().BoolP("glob", "g", false, "Glob")
+	cmd.Flags().BoolP("whole-name", "w", false, "Whole name")
+	cmd.Flags().BoolP("full-path", "p", false, "Full path")
+	return cmd
+}
+
+// newContentSearchTestCmd returns a command with the content search flags defined.
+func newContentSearchTestCmd() *cobra.Command {
+	cmd := &cobra.Command{}
+	addIgnoreFlags(cmd, true)
+	addMatchFlags(cmd)
+	addResultFlags(cmd)
+	cmd.Flags().IntP("context", "C", 0, "Context")
+	cmd.Flags().IntP("after-context", "A", 0, "After")
+	cmd.Flags().IntP("before-context", "B", 0, "Before")
+	cmd.Flags().BoolP("count", "c", false, "Count")
+	cmd.Flags().BoolP("files-with-matches", "l", false, "Files with matches")
+	cmd.Flags().String("binary", "skip", "Binary")
+	cmd.Flags().String("encoding", "auto", "Encoding")
+	return cmd
+}
+
 // TestRunSearch_NotEnoughArgs verifies runSearch returns an error when no args are provided.
 func TestRunSearch_NotEnoughArgs(t *testing.T) {
-	err := runSearch([]string{}, func(q, d string) ([]string, error) { return nil, nil })
+	err := runSearch([]string{}, func(q, d string) ([]string, error) { return nil, nil }, nil)
 	if err == nil || !strings.Contains(err.Error(), "not enough arguments") {
 		t.Fatalf("expected 'not enough arguments' error, got: %v", err)
 	}
@@ -24,7 +52,7 @@ func TestRunSearch_HelperFuncError(t *testing.T) {
 	wantErr := errors.New("helper failure")
 	err := runSearch(args, func(q, d string) ([]string, error) {
 		return nil, wantErr
-	})
+	}, nil)
 	if err == nil || !errors.Is(err, wantErr) {
 		t.Fatalf("expected helper error to be returned, got: %v", err)
 	}
@@ -35,7 +63,7 @@ func TestRunSearch_NoResults(t *testing.T) {
 	args := []string{"query", "/some/dir"}
 	err := runSearch(args, func(q, d string) ([]string, error) {
 		return []string{}, nil
-	})
+	}, nil)
 	if err == nil || !strings.Contains(err.Error(), "no files found for search criteria") {
 		t.Fatalf("expected 'no files found for search criteria' error, got: %v", err)
 	}
@@ -47,7 +75,7 @@ func TestRunSearch_PrintsResults(t *testing.T) {
 	out := captureOutput(func() {
 		err := runSearch(args, func(q, d string) ([]string, error) {
 			return []string{"/path/one", "/path/two"}, nil
-		})
+		}, nil)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -76,7 +104,7 @@ func TestRunNameAndContentSearch_Integration(t *testing.T) {
 	// run name search
 	outName := captureOutput(func() {
 		// runNameSearch expects (cmd *cobra.Command, args []string)
-		cmd := &cobra.Command{}
+		cmd := newNameSearchTestCmd()
 		err := runNameSearch(cmd, []string{"match", td})
 		if err != nil {
 			t.Fatalf("runNameSearch returned error: %v", err)
@@ -88,7 +116,7 @@ func TestRunNameAndContentSearch_Integration(t *testing.T) {
 
 	// run content search
 	outContent := captureOutput(func() {
-		cmd := &cobra.Command{}
+		cmd := newContentSearchTestCmd()
 		err := runContentSearch(cmd, []string{"hello", td})
 		if err != nil {
 			t.Fatalf("runContentSearch returned error: %v", err)
@@ -98,3 +126,161 @@ func TestRunNameAndContentSearch_Integration(t *testing.T) {
 		t.Fatalf("expected content search to print %s, got: %q", matchName, outContent)
 	}
 }
+
+// TestRunNameSearch_GlobAndIgnoreCase verifies glob queries against relative paths and case-insensitive matching.
+func TestRunNameSearch_GlobAndIgnoreCase(t *testing.T) {
+	td := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(td, "docs", "api"), 0o755); err != nil {
+		t.Fatalf("failed to create dirs: %v", err)
+	}
+	for _, rel := range []string{"docs/api/Intro.MD", "docs/readme.txt", "main.go"} {
+		if err := os.WriteFile(filepath.Join(td, rel), []byte("x"), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", rel, err)
+		}
+	}
+
+	cmd := newNameSearchTestCmd()
+	_ = cmd.Flags().Set("glob", "true")
+	_ = cmd.Flags().Set("ignore-case", "true")
+	out := captureOutput(func() {
+		if err := runNameSearch(cmd, []string{"docs/**/*.md", td}); err != nil {
+			t.Fatalf("runNameSearch returned error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "Intro.MD") || strings.Contains(out, "readme.txt") || strings.Contains(out, "main.go") {
+		t.Fatalf("expected only Intro.MD to match, got: %q", out)
+	}
+}
+
+// TestRunNameSearch_InvalidRegex verifies that a bad regular expression is reported.
+func TestRunNameSearch_InvalidRegex(t *testing.T) {
+	cmd := newNameSearchTestCmd()
+	_ = cmd.Flags().Set("regex", "true")
+	if err := runNameSearch(cmd, []string{"(unclosed", t.TempDir()}); err == nil {
+		t.Fatalf("expected error for invalid regex")
+	}
+}
+
+// TestRunContentSearch_LineOutput verifies grep-style output, context lines and --count.
+func TestRunContentSearch_LineOutput(t *testing.T) {
+	td := t.TempDir()
+	file := filepath.Join(td, "notes.txt")
+	content := "one\ntwo\nthree needle\nfour\nfive\nsix\nseven needle\n"
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	cmd := newContentSearchTestCmd()
+	out := captureOutput(func() {
+		if err := runContentSearch(cmd, []string{"needle", td}); err != nil {
+			t.Fatalf("runContentSearch returned error: %v", err)
+		}
+	})
+	if !strings.Contains(out, file+":3:7:three needle") || !strings.Contains(out, file+":7:7:seven needle") {
+		t.Fatalf("expected path:line:col:text output, got: %q", out)
+	}
+
+	cmd = newContentSearchTestCmd()
+	_ = cmd.Flags().Set("context", "1")
+	out = captureOutput(func() {
+		if err := runContentSearch(cmd, []string{"needle", td}); err != nil {
+			t.Fatalf("runContentSearch returned error: %v", err)
+		}
+	})
+	if !strings.Contains(out, file+"-2-two") || !strings.Contains(out, file+"-4-four") || !strings.Contains(out, "--") {
+		t.Fatalf("expected context lines and a group separator, got: %q", out)
+	}
+
+	// -A 0 overrides -C for the lines after a match
+	cmd = newContentSearchTestCmd()
+	_ = cmd.Flags().Set("context", "1")
+	_ = cmd.Flags().Set("after-context", "0")
+	out = captureOutput(func() {
+		if err := runContentSearch(cmd, []string{"needle", td}); err != nil {
+			t.Fatalf("runContentSearch returned error: %v", err)
+		}
+	})
+	if !strings.Contains(out, file+"-2-two") || strings.Contains(out, file+"-4-four") {
+		t.Fatalf("expected only the lines before each match, got: %q", out)
+	}
+
+	cmd = newContentSearchTestCmd()
+	_ = cmd.Flags().Set("count", "true")
+	out = captureOutput(func() {
+		if err := runContentSearch(cmd, []string{"needle", td}); err != nil {
+			t.Fatalf("runContentSearch returned error: %v", err)
+		}
+	})
+	if strings.TrimSpace(out) != file+":2" {
+		t.Fatalf("expected count output, got: %q", out)
+	}
+}
+
+// TestRunContentSearch_BinaryMatchOnly verifies binary files are skipped by default and reported with --binary=match-only.
+func TestRunContentSearch_BinaryMatchOnly(t *testing.T) {
+	td := t.TempDir()
+	blob := filepath.Join(td, "blob.bin")
+	if err := os.WriteFile(blob, []byte("\x00\x01needle\x02"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	cmd := newContentSearchTestCmd()
+	if err := runContentSearch(cmd, []string{"needle", td}); err == nil {
+		t.Fatalf("expected binary file to be skipped by default")
+	}
+
+	cmd = newContentSearchTestCmd()
+	_ = cmd.Flags().Set("binary", "match-only")
+	out := captureOutput(func() {
+		if err := runContentSearch(cmd, []string{"needle", td}); err != nil {
+			t.Fatalf("runContentSearch returned error: %v", err)
+		}
+	})
+	if strings.TrimSpace(out) != "Binary file "+blob+" matches" {
+		t.Fatalf("expected binary match message, got: %q", out)
+	}
+}
+
+// newFuzzySearchTestCmd returns a command with the fuzzy search flags defined.
+func newFuzzySearchTestCmd() *cobra.Command {
+	cmd := &cobra.Command{}
+	addIgnoreFlags(cmd, true)
+	addWalkFlags(cmd)
+	addResultFlags(cmd)
+	cmd.Flags().IntP("top", "n", 20, "Top")
+	cmd.Flags().Bool("scores", false, "Scores")
+	return cmd
+}
+
+// TestRunFuzzySearch_RanksResults verifies the best match is printed first and --top limits the output.
+func TestRunFuzzySearch_RanksResults(t *testing.T) {
+	td := t.TempDir()
+	for _, rel := range []string{"internal/misc/reader.go", "cmd/readme.go", "README.md"} {
+		path := filepath.Join(td, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	cmd := newFuzzySearchTestCmd()
+	cmd.Flags().Set("top", "2")
+	out := captureOutput(func() {
+		if err := runFuzzySearch(cmd, []string{"readme", td}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 results; got: %q", out)
+	}
+	if lines[0] != filepath.Join(td, "README.md") {
+		t.Fatalf("expected README.md to rank first; got: %q", out)
+	}
+
+	if err := runFuzzySearch(newFuzzySearchTestCmd(), []string{"zzz", td}); err == nil {
+		t.Fatalf("expected an error when nothing matches")
+	}
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"f/helper"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func runSwap(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("expected 2 paths, got %d", len(args))
+	}
+	if err := commandContext(cmd).Err(); err != nil {
+		return err
+	}
+	if err := helper.SwapPaths(args[0], args[1]); err != nil {
+		return err
+	}
+	fmt.Println(style.Success(fmt.Sprintf("Swapped %s and %s", args[0], args[1])))
+	return nil
+}
+
+var swapCmd = &cobra.Command{
+	Use:   "swap <first> <second>",
+	Short: "Exchange the names of two files or directories",
+	Long: `Exchange the names of two files or directories, so each takes the other's place.
+On Linux the swap is atomic; elsewhere it goes through a temporary name and is undone if a step fails.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runSwap,
+}
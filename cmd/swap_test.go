@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// TestRunSwap verifies that swap exchanges two file names and fails for a missing file.
+func TestRunSwap(t *testing.T) {
+	td := t.TempDir()
+	a, b := filepath.Join(td, "a.txt"), filepath.Join(td, "b.txt")
+	for path, text := range map[string]string{a: "A", b: "B"} {
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	out := captureOutput(func() {
+		if err := runSwap(&cobra.Command{}, []string{a, b}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !contains(out, "Swapped") {
+		t.Fatalf("expected Swapped message, got: %q", out)
+	}
+	if data, _ := os.ReadFile(a); string(data) != "B" {
+		t.Fatalf("expected a.txt to hold B, got %q", data)
+	}
+	if data, _ := os.ReadFile(b); string(data) != "A" {
+		t.Fatalf("expected b.txt to hold A, got %q", data)
+	}
+
+	if err := runSwap(&cobra.Command{}, []string{a, filepath.Join(td, "missing")}); err == nil {
+		t.Fatalf("expected an error for a missing file")
+	}
+}
@@ -10,14 +10,24 @@ import (
 // It returns the captured output as a string. Tests in this package use this helper
 // to avoid duplicating stdout-capture logic across multiple test files.
 func captureOutput(f func()) string {
-	orig := os.Stdout
+	return captureFile(&os.Stdout, f)
+}
+
+// captureStderr is like captureOutput for stderr, where errors and warnings go.
+func captureStderr(f func()) string {
+	return captureFile(&os.Stderr, f)
+}
+
+// captureFile runs f with *file redirected to a pipe and returns what was written.
+func captureFile(file **os.File, f func()) string {
+	orig := *file
 	r, w, err := os.Pipe()
 	if err != nil {
 		// If we can't create a pipe, run the function normally and return an empty string.
 		f()
 		return ""
 	}
-	os.Stdout = w
+	*file = w
 
 	outC := make(chan string)
 	go func() {
@@ -26,12 +36,12 @@ func captureOutput(f func()) string {
 		outC <- buf.String()
 	}()
 
-	// Run the function while stdout is redirected.
+	// Run the function while the file is redirected.
 	f()
 
-	// Close writer and restore original stdout.
+	// Close writer and restore the original file.
 	_ = w.Close()
-	os.Stdout = orig
+	*file = orig
 
 	// Return captured output.
 	return <-outC
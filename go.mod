@@ -2,7 +2,10 @@ module f
 
 go 1.23.2
 
-require github.com/spf13/cobra v1.9.1
+require (
+	github.com/spf13/cobra v1.9.1
+	golang.org/x/sys v0.35.0
+)
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
@@ -0,0 +1,221 @@
+package helper
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// ColorMode controls when ANSI colors are written to the output.
+type ColorMode int
+
+const (
+	ColorAuto ColorMode = iota
+	ColorAlways
+	ColorNever
+)
+
+// defaultLSColors mirrors the defaults used by GNU ls when LS_COLORS is unset.
+const defaultLSColors = "di=01;34:ln=01;36:so=01;35:pi=40;33:ex=01;32:bd=40;33;01:cd=40;33;01:su=37;41:sg=30;43:tw=30;42:ow=34;42:st=37;44"
+
+const (
+	matchStyle   = "01;31"
+	successStyle = "32"
+	failureStyle = "31"
+	warningStyle = "33"
+)
+
+// ParseColorMode parses the value of the --color flag.
+func ParseColorMode(s string) (ColorMode, error) {
+	switch s {
+	case "", "auto":
+		return ColorAuto, nil
+	case "always":
+		return ColorAlways, nil
+	case "never":
+		return ColorNever, nil
+	}
+	return ColorAuto, fmt.Errorf("invalid color mode %q (expected auto, always or never)", s)
+}
+
+// Styler applies ANSI styles to output text. A disabled Styler returns text
+// unchanged, so callers can style unconditionally.
+type Styler struct {
+	enabled  bool
+	types    map[string]string
+	suffixes []lsSuffix
+}
+
+type lsSuffix struct {
+	suffix string
+	style  string
+}
+
+// NewStyler creates a Styler for the given mode. In auto mode colors are only
+// enabled when out is a terminal, NO_COLOR is unset and TERM is not "dumb".
+func NewStyler(mode ColorMode, out *os.File) *Styler {
+	s := &Styler{enabled: colorEnabled(mode, out)}
+	lsColors := os.Getenv("LS_COLORS")
+	if lsColors == "" {
+		lsColors = defaultLSColors
+	}
+	s.types, s.suffixes = parseLSColors(lsColors)
+	return s
+}
+
+// colorEnabled decides whether colors should be written for the given mode.
+func colorEnabled(mode ColorMode, out *os.File) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isTerminal(out)
+}
+
+// isTerminal reports whether f is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	if f == nil {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// parseLSColors splits an LS_COLORS value into file type styles and filename suffix styles.
+func parseLSColors(value string) (map[string]string, []lsSuffix) {
+	types := map[string]string{}
+	var suffixes []lsSuffix
+	for _, field := range strings.Split(value, ":") {
+		key, style, ok := strings.Cut(field, "=")
+		if !ok || key == "" || style == "" {
+			continue
+		}
+		if strings.HasPrefix(key, "*") {
+			suffixes = append(suffixes, lsSuffix{suffix: strings.ToLower(key[1:]), style: style})
+		} else {
+			types[key] = style
+		}
+	}
+	return types, suffixes
+}
+
+// Enabled reports whether the Styler writes escape codes.
+func (s *Styler) Enabled() bool {
+	return s.enabled
+}
+
+// paint wraps text in the given SGR sequence.
+func (s *Styler) paint(style, text string) string {
+	if !s.enabled || style == "" || text == "" {
+		return text
+	}
+	return "\x1b[" + style + "m" + text + "\x1b[0m"
+}
+
+// FileName styles a file name according to its mode and LS_COLORS.
+func (s *Styler) FileName(name string, mode fs.FileMode) string {
+	if !s.enabled {
+		return name
+	}
+	return s.paint(s.fileStyle(name, mode), name)
+}
+
+// fileStyle picks the LS_COLORS entry for a file, in the same precedence order as GNU ls.
+func (s *Styler) fileStyle(name string, mode fs.FileMode) string {
+	switch {
+	case mode&fs.ModeSymlink != 0:
+		return s.types["ln"]
+	case mode.IsDir():
+		sticky := mode&fs.ModeSticky != 0
+		otherWritable := mode.Perm()&0o002 != 0
+		switch {
+		case sticky && otherWritable && s.types["tw"] != "":
+			return s.types["tw"]
+		case otherWritable && s.types["ow"] != "":
+			return s.types["ow"]
+		case sticky && s.types["st"] != "":
+			return s.types["st"]
+		}
+		return s.types["di"]
+	case mode&fs.ModeNamedPipe != 0:
+		return s.types["pi"]
+	case mode&fs.ModeSocket != 0:
+		return s.types["so"]
+	case mode&fs.ModeCharDevice != 0:
+		return s.types["cd"]
+	case mode&fs.ModeDevice != 0:
+		return s.types["bd"]
+	case mode&fs.ModeSetuid != 0 && s.types["su"] != "":
+		return s.types["su"]
+	case mode&fs.ModeSetgid != 0 && s.types["sg"] != "":
+		return s.types["sg"]
+	case mode.Perm()&0o111 != 0 && s.types["ex"] != "":
+		return s.types["ex"]
+	}
+	lower := strings.ToLower(name)
+	for _, suffix := range s.suffixes {
+		if strings.HasSuffix(lower, suffix.suffix) {
+			return suffix.style
+		}
+	}
+	return s.types["fi"]
+}
+
+// Highlight styles the byte ranges of text given by spans as matches.
+// Spans must be sorted and non-overlapping.
+func (s *Styler) Highlight(text string, spans [][]int) string {
+	if !s.enabled || len(spans) == 0 {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, span := range spans {
+		b.WriteString(text[last:span[0]])
+		b.WriteString(s.paint(matchStyle, text[span[0]:span[1]]))
+		last = span[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// Success styles a status line reporting a completed operation.
+func (s *Styler) Success(text string) string {
+	return s.paint(successStyle, text)
+}
+
+// Failure styles a status line reporting a failed operation.
+func (s *Styler) Failure(text string) string {
+	return s.paint(failureStyle, text)
+}
+
+// Warning styles a status line reporting a non-fatal problem.
+func (s *Styler) Warning(text string) string {
+	return s.paint(warningStyle, text)
+}
+
+// LiteralSpans returns the byte ranges of every non-overlapping occurrence of query in text.
+func LiteralSpans(text, query string) [][]int {
+	if query == "" {
+		return nil
+	}
+	var spans [][]int
+	offset := 0
+	for {
+		i := strings.Index(text[offset:], query)
+		if i < 0 {
+			return spans
+		}
+		start := offset + i
+		spans = append(spans, []int{start, start + len(query)})
+		offset = start + len(query)
+	}
+}
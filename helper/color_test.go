@@ -0,0 +1,95 @@
+package helper
+
+import (
+	"io/fs"
+	"testing"
+)
+
+// TestParseColorMode verifies the accepted --color values and rejects unknown ones.
+func TestParseColorMode(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]ColorMode{"": ColorAuto, "auto": ColorAuto, "always": ColorAlways, "never": ColorNever}
+	for in, want := range cases {
+		got, err := ParseColorMode(in)
+		if err != nil {
+			t.Fatalf("ParseColorMode(%q) returned error: %v", in, err)
+		}
+		if got != want {
+			t.Fatalf("ParseColorMode(%q) = %v, want %v", in, got, want)
+		}
+	}
+	if _, err := ParseColorMode("sometimes"); err == nil {
+		t.Fatalf("expected error for invalid color mode")
+	}
+}
+
+// TestStylerDisabled ensures a disabled styler leaves text untouched.
+func TestStylerDisabled(t *testing.T) {
+	t.Setenv("LS_COLORS", "")
+
+	s := NewStyler(ColorNever, nil)
+	if s.Enabled() {
+		t.Fatalf("expected styler to be disabled")
+	}
+	if got := s.FileName("dir", fs.ModeDir|0o755); got != "dir" {
+		t.Fatalf("expected plain name, got %q", got)
+	}
+	if got := s.Highlight("needle", [][]int{{0, 3}}); got != "needle" {
+		t.Fatalf("expected plain text, got %q", got)
+	}
+	if got := s.Success("ok"); got != "ok" {
+		t.Fatalf("expected plain text, got %q", got)
+	}
+}
+
+// TestStylerAutoRespectsNoColor ensures NO_COLOR disables auto mode but not always mode.
+func TestStylerAutoRespectsNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	if NewStyler(ColorAuto, nil).Enabled() {
+		t.Fatalf("expected auto mode to be disabled with NO_COLOR set")
+	}
+	if !NewStyler(ColorAlways, nil).Enabled() {
+		t.Fatalf("expected always mode to ignore NO_COLOR")
+	}
+}
+
+// TestStylerFileNameUsesLSColors checks type, permission and extension lookups.
+func TestStylerFileNameUsesLSColors(t *testing.T) {
+	t.Setenv("LS_COLORS", "di=01;34:ex=01;32:ow=34;42:*.tar=01;31:fi=0")
+
+	s := NewStyler(ColorAlways, nil)
+	cases := []struct {
+		name string
+		mode fs.FileMode
+		want string
+	}{
+		{"dir", fs.ModeDir | 0o755, "\x1b[01;34mdir\x1b[0m"},
+		{"shared", fs.ModeDir | 0o777, "\x1b[34;42mshared\x1b[0m"},
+		{"run.sh", 0o755, "\x1b[01;32mrun.sh\x1b[0m"},
+		{"backup.TAR", 0o644, "\x1b[01;31mbackup.TAR\x1b[0m"},
+		{"notes.txt", 0o644, "\x1b[0mnotes.txt\x1b[0m"},
+	}
+	for _, c := range cases {
+		if got := s.FileName(c.name, c.mode); got != c.want {
+			t.Fatalf("FileName(%q) = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+// TestHighlightAndLiteralSpans verifies that every occurrence of a query is highlighted.
+func TestHighlightAndLiteralSpans(t *testing.T) {
+	t.Parallel()
+
+	spans := LiteralSpans("abcabc", "bc")
+	if len(spans) != 2 || spans[0][0] != 1 || spans[1][0] != 4 {
+		t.Fatalf("unexpected spans: %v", spans)
+	}
+
+	s := &Styler{enabled: true}
+	want := "a\x1b[01;31mbc\x1b[0ma\x1b[01;31mbc\x1b[0m"
+	if got := s.Highlight("abcabc", spans); got != want {
+		t.Fatalf("Highlight = %q, want %q", got, want)
+	}
+}
@@ -1,54 +1,162 @@
 package helper
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 type workerFunc func(os.FileInfo, string) error
 
-func RunConcurrent(task workerFunc, workerCount int, matches []string) error {
+// PathFailure is a path a batch failed on and the cause.
+type PathFailure struct {
+	Path string
+	Err  error
+}
+
+func (f PathFailure) Error() string { return fmt.Sprintf("%s: %v", f.Path, f.Err) }
+
+func (f PathFailure) Unwrap() error { return f.Err }
+
+// BatchError is returned by RunConcurrent when any path failed or was never
+// started. It lists every failure with its cause, the paths that worked and the
+// paths skipped after the context was cancelled, all in input order.
+type BatchError struct {
+	Succeeded []string
+	Failed    []PathFailure
+	Skipped   []string
+	// Cause is why the skipped paths were never started, such as context.Canceled.
+	Cause error
+}
+
+func (e *BatchError) Error() string {
+	if len(e.Failed) == 1 && len(e.Skipped) == 0 {
+		return e.Failed[0].Error()
+	}
+	summary := fmt.Sprintf("%d succeeded, %d failed", len(e.Succeeded), len(e.Failed))
+	if len(e.Skipped) > 0 {
+		summary += fmt.Sprintf(", %d skipped (%v)", len(e.Skipped), e.Cause)
+	}
+	if len(e.Failed) == 0 {
+		return summary
+	}
+	lines := make([]string, len(e.Failed))
+	for i, failure := range e.Failed {
+		lines[i] = failure.Error()
+	}
+	return fmt.Sprintf("%s:\n  %s", summary, strings.Join(lines, "\n  "))
+}
+
+// Unwrap returns the individual failures and the cause of any skipped paths,
+// so errors.Is and errors.As see them.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed)+1)
+	for _, failure := range e.Failed {
+		errs = append(errs, failure)
+	}
+	if len(e.Skipped) > 0 && e.Cause != nil {
+		errs = append(errs, e.Cause)
+	}
+	return errs
+}
+
+// RunConcurrent runs task on each of matches with workerCount workers. Every
+// path is attempted; if any fail, a *BatchError listing all of them is returned.
+// Once ctx is cancelled no new paths are started, but tasks already running
+// are left to finish; the paths never started are listed as skipped.
+// With a workerCount of AutoJobs, the count starts from the storage the
+// matches are on and is tuned to the throughput while the batch runs.
+func RunConcurrent(ctx context.Context, task workerFunc, workerCount int, matches []string) error {
+	return runConcurrent(ctx, task, workerCount, matches, matches)
+}
+
+// runConcurrent is RunConcurrent with the paths whose storage picks an
+// automatic worker count given separately, so a copy can include its destination.
+func runConcurrent(ctx context.Context, task workerFunc, workerCount int, matches []string, storagePaths []string) error {
 	var wg sync.WaitGroup
-	jobs := make(chan string)
-	errs := make(chan error, len(matches))
+	jobs := make(chan int)
+	// Each worker writes only the slots of the jobs it took
+	errs := make([]error, len(matches))
+	started := make([]bool, len(matches))
+
+	workers := max(workerCount, 1)
+	limit := newWorkerLimit(workers)
+	var doneTasks atomic.Int64
+	// Only the growth of progress between measurements matters
+	progress := func() int64 {
+		return bytesWritten.Load() + doneTasks.Load()*taskOverheadBytes
+	}
+	if workerCount == AutoJobs {
+		// Every worker the tuner may allow is started, and the limit decides how many run
+		workers = maxAutoJobs
+		tuner := newJobTuner(storageJobs(storagePaths))
+		limit.set(tuner.jobs)
+		tuned := make(chan struct{})
+		defer close(tuned)
+		go tuneWorkers(limit, tuner, tuneInterval, progress, tuned)
+	}
 
 	worker := func() {
 		defer wg.Done()
-		for match := range jobs {
-			info, err := os.Stat(match)
-			if err != nil {
-				errs <- err
-				continue
+		for {
+			limit.acquire()
+			i, ok := <-jobs
+			if !ok {
+				limit.release()
+				return
 			}
-
-			err = task(info, match)
-
-			if err != nil {
-				errs <- err
+			info, err := os.Stat(matches[i])
+			if err == nil {
+				err = task(info, matches[i])
 			}
+			if err == nil {
+				doneTasks.Add(1)
+			}
+			errs[i] = err
+			limit.release()
 		}
 	}
 
-	for range workerCount {
+	for range workers {
 		wg.Add(1)
 		go worker()
 	}
 
-	go func() {
-		for _, match := range matches {
-			jobs <- match
+send:
+	for i := range matches {
+		// A cancelled context wins over an idle worker
+		if ctx.Err() != nil {
+			break
 		}
-		close(jobs)
-	}()
-
+		select {
+		case jobs <- i:
+			started[i] = true
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(jobs)
 	wg.Wait()
-	close(errs)
 
-	for err := range errs {
-		if err != nil {
-			return err
+	batchErr := &BatchError{}
+	for i, err := range errs {
+		switch {
+		case !started[i]:
+			batchErr.Skipped = append(batchErr.Skipped, matches[i])
+		case err != nil:
+			batchErr.Failed = append(batchErr.Failed, PathFailure{Path: matches[i], Err: err})
+		default:
+			batchErr.Succeeded = append(batchErr.Succeeded, matches[i])
 		}
 	}
-
+	if len(batchErr.Skipped) > 0 {
+		batchErr.Cause = ctx.Err()
+	}
+	if len(batchErr.Failed) > 0 || len(batchErr.Skipped) > 0 {
+		return batchErr
+	}
 	return nil
 }
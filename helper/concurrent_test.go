@@ -1,8 +1,13 @@
 package helper
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync/atomic"
 	"testing"
 )
@@ -34,7 +39,7 @@ func TestRunConcurrentSuccess(t *testing.T) {
 		return nil
 	}
 
-	if err := RunConcurrent(task, 3, matches); err != nil {
+	if err := RunConcurrent(context.Background(), task, 3, matches); err != nil {
 		t.Fatalf("expected nil error, got: %v", err)
 	}
 
@@ -73,7 +78,7 @@ func TestRunConcurrentTaskError(t *testing.T) {
 		return nil
 	}
 
-	err = RunConcurrent(task, 2, matches)
+	err = RunConcurrent(context.Background(), task, 2, matches)
 	if err == nil {
 		t.Fatalf("expected an error but got nil")
 	}
@@ -97,11 +102,93 @@ func TestRunConcurrentStatError(t *testing.T) {
 		return nil
 	}
 
-	err := RunConcurrent(task, 1, matches)
+	err := RunConcurrent(context.Background(), task, 1, matches)
 	if err == nil {
 		t.Fatalf("expected an error from os.Stat but got nil")
 	}
-	if !os.IsNotExist(err) {
+	if !errors.Is(err, fs.ErrNotExist) {
 		t.Fatalf("expected not-exist error, got: %v", err)
 	}
 }
+
+// TestRunConcurrentBatchError verifies that every failure is reported with its
+// path and cause, in input order, alongside the paths that worked.
+func TestRunConcurrentBatchError(t *testing.T) {
+	t.Parallel()
+
+	tdir := t.TempDir()
+	var matches []string
+	for i := range 6 {
+		path := filepath.Join(tdir, fmt.Sprintf("file%d", i))
+		if i != 4 {
+			if err := os.WriteFile(path, nil, 0o644); err != nil {
+				t.Fatalf("write %s: %v", path, err)
+			}
+		}
+		matches = append(matches, path)
+	}
+
+	failure := errors.New("task failure")
+	task := func(info os.FileInfo, path string) error {
+		if path == matches[1] || path == matches[3] {
+			return failure
+		}
+		return nil
+	}
+
+	err := RunConcurrent(context.Background(), task, 3, matches)
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected a *BatchError, got %v", err)
+	}
+	if len(batchErr.Failed) != 3 || len(batchErr.Succeeded) != 3 {
+		t.Fatalf("expected 3 failures and 3 successes, got %+v", batchErr)
+	}
+	for i, want := range []string{matches[1], matches[3], matches[4]} {
+		if batchErr.Failed[i].Path != want {
+			t.Fatalf("failure %d is for %s, want %s", i, batchErr.Failed[i].Path, want)
+		}
+	}
+	if !errors.Is(err, failure) || !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected both causes to be reachable, got %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "3 succeeded, 3 failed:") {
+		t.Fatalf("unexpected message %q", err.Error())
+	}
+}
+
+// TestRunConcurrentCancel verifies that cancelling the context stops new paths
+// from starting, lets the running task finish, and lists the rest as skipped.
+func TestRunConcurrentCancel(t *testing.T) {
+	t.Parallel()
+
+	tdir := t.TempDir()
+	var matches []string
+	for i := range 5 {
+		path := filepath.Join(tdir, fmt.Sprintf("file%d", i))
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+		matches = append(matches, path)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	task := func(info os.FileInfo, path string) error {
+		// The first task is interrupted while it runs
+		cancel()
+		return nil
+	}
+
+	err := RunConcurrent(ctx, task, 1, matches)
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected a *BatchError, got %v", err)
+	}
+	if len(batchErr.Succeeded) != 1 || batchErr.Succeeded[0] != matches[0] || len(batchErr.Skipped) != 4 {
+		t.Fatalf("expected the first path to finish and the rest to be skipped, got %+v", batchErr)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancellation to be reachable, got %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+package helper
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+)
+
+const (
+	// scanBufferSize is the read buffer used for each file. Lines longer than
+	// this are scanned in pieces, so memory use does not depend on file size.
+	scanBufferSize = 64 * 1024
+	// scanOverlap is how much of the previous piece of a long line is kept so
+	// that matches spanning two pieces are still found.
+	scanOverlap = 4 * 1024
+)
+
+// ContentLine is a line of a content search result: either a match or a
+// context line around one.
+type ContentLine struct {
+	// Number is the 1-based line number.
+	Number int
+	// Column is the 1-based byte column of the first match, or 0 for context lines.
+	Column int
+	// Text is the line without its line ending. Lines longer than the read
+	// buffer are cut down to the part around the first match.
+	Text string
+	// Spans are the byte ranges of the matches within Text.
+	Spans [][]int
+	// Binary marks the single match reported for a binary file in match-only
+	// mode. Its text is not meaningful and should not be printed.
+	Binary bool
+}
+
+// IsMatch reports whether the line contains a match rather than being context.
+func (l ContentLine) IsMatch() bool {
+	return len(l.Spans) > 0
+}
+
+// ContentResult holds the matching lines of one file. Lines are in file order,
+// and a gap between consecutive line numbers separates groups of context.
+type ContentResult struct {
+	Path  string
+	Count int
+	Lines []ContentLine
+}
+
+// SearchContent searches the files in dir line by line and returns the matching
+// lines of every file with at least one match, with context lines as requested in opts.
+// If some paths could not be read, the results found elsewhere are returned along with a *WalkError.
+func SearchContent(ctx context.Context, query string, dir string, opts SearchOptions) ([]ContentResult, error) {
+	var results []ContentResult
+	err := StreamContent(ctx, query, dir, opts, func(path string, line ContentLine) error {
+		if len(results) == 0 || results[len(results)-1].Path != path {
+			results = append(results, ContentResult{Path: path})
+		}
+		result := &results[len(results)-1]
+		if line.IsMatch() {
+			result.Count++
+		}
+		result.Lines = append(result.Lines, line)
+		return nil
+	})
+	if opts.SortByPath {
+		sort.Slice(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+	}
+	return results, err
+}
+
+// StreamContent searches the files in dir like SearchContent, but calls fn with
+// each result line as soon as it is found instead of collecting them. Files are
+// read through a fixed-size buffer and lines are not held once delivered, so
+// memory use stays bounded however large the files or the number of matches are.
+//
+// Files are scanned concurrently, but fn is never called concurrently and the
+// lines of one file are always delivered together: a worker takes the output
+// when its file's first line is found and keeps it until the file is done,
+// while other workers keep scanning up to their own first line.
+func StreamContent(ctx context.Context, query string, dir string, opts SearchOptions, fn func(path string, line ContentLine) error) error {
+	matcher, err := NewMatcher(query, opts.Match)
+	if err != nil {
+		return err
+	}
+	var output sync.Mutex
+	err = walkFiles(ctx, dir, opts, func(path string, d fs.DirEntry) error {
+		if d.IsDir() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		text, binary, err := decodeContent(bufio.NewReaderSize(file, scanBufferSize), opts.Encoding)
+		if err != nil {
+			return err
+		}
+		fileOpts := opts
+		if binary {
+			switch opts.Binary {
+			case BinarySkip:
+				return nil
+			case BinaryMatchOnly:
+				fileOpts.Before, fileOpts.After, fileOpts.FilesWithMatches = 0, 0, true
+			}
+		}
+		holding := false
+		defer func() {
+			if holding {
+				output.Unlock()
+			}
+		}()
+		return scanContent(text, matcher, fileOpts, func(line ContentLine) error {
+			if !holding {
+				output.Lock()
+				holding = true
+			}
+			if binary && opts.Binary == BinaryMatchOnly {
+				line = ContentLine{Number: line.Number, Column: line.Column, Binary: true, Spans: line.Spans}
+			}
+			return fn(path, line)
+		})
+	})
+	return notFound(err)
+}
+
+// scanContent reads r line by line and calls emit with every matching line and
+// the context lines around it.
+func scanContent(r io.Reader, matcher Matcher, opts SearchOptions, emit func(ContentLine) error) error {
+	reader := bufio.NewReaderSize(r, scanBufferSize)
+	var before []ContentLine
+	afterLeft := 0
+
+	for number := 1; ; number++ {
+		line, err := readContentLine(reader, matcher)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line.Number = number
+
+		if !line.IsMatch() {
+			switch {
+			case afterLeft > 0:
+				if err := emit(line); err != nil {
+					return err
+				}
+				afterLeft--
+			case opts.Before > 0:
+				before = append(before, line)
+				if len(before) > opts.Before {
+					before = before[1:]
+				}
+			}
+			continue
+		}
+
+		for _, context := range before {
+			if err := emit(context); err != nil {
+				return err
+			}
+		}
+		before = before[:0]
+		if err := emit(line); err != nil {
+			return err
+		}
+		afterLeft = opts.After
+		// Stop reading the file once its first match is all that is needed
+		if opts.FilesWithMatches {
+			return nil
+		}
+	}
+}
+
+// readContentLine reads the next line from reader and finds its matches.
+// Lines longer than the buffer are matched piece by piece, keeping the last
+// scanOverlap bytes of each piece so matches across piece boundaries are found.
+// It returns io.EOF when there are no more lines.
+func readContentLine(reader *bufio.Reader, matcher Matcher) (ContentLine, error) {
+	var line ContentLine
+	var carry []byte
+	offset := 0
+	first := true
+
+	for {
+		piece, err := reader.ReadSlice('\n')
+		if err != nil && err != bufio.ErrBufferFull && err != io.EOF {
+			return line, err
+		}
+		if err == io.EOF && len(piece) == 0 && first {
+			return line, io.EOF
+		}
+		lineEnded := err != bufio.ErrBufferFull
+		if lineEnded {
+			piece = bytes.TrimRight(piece, "\r\n")
+		}
+
+		// Short lines, which fit in the buffer, are matched directly
+		if first && lineEnded {
+			line.Text = string(piece)
+			line.Spans = matcher.FindAll(line.Text)
+			if len(line.Spans) > 0 {
+				line.Column = line.Spans[0][0] + 1
+			}
+			return line, nil
+		}
+
+		window := append(carry, piece...)
+		if first {
+			line.Text = string(piece)
+		}
+		if !line.IsMatch() {
+			text := string(window)
+			if spans := matcher.FindAll(text); len(spans) > 0 {
+				line.Text = text
+				line.Spans = spans
+				line.Column = offset + spans[0][0] + 1
+			}
+		}
+		if lineEnded {
+			return line, nil
+		}
+
+		keep := min(scanOverlap, len(window))
+		offset += len(window) - keep
+		carry = append([]byte(nil), window[len(window)-keep:]...)
+		first = false
+	}
+}
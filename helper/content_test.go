@@ -0,0 +1,149 @@
+package helper
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestScanContentContext verifies that overlapping context is merged and that
+// match columns and spans are reported.
+func TestScanContentContext(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewMatcher("x", MatchOptions{})
+	if err != nil {
+		t.Fatalf("NewMatcher returned error: %v", err)
+	}
+	content := "a\nb x\nc\nd x\ne\nf\ng\nh x\r\n"
+	var result ContentResult
+	err = scanContent(strings.NewReader(content), m, SearchOptions{Before: 1, After: 1}, func(line ContentLine) error {
+		if line.IsMatch() {
+			result.Count++
+		}
+		result.Lines = append(result.Lines, line)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("scanContent returned error: %v", err)
+	}
+
+	if result.Count != 3 {
+		t.Fatalf("expected 3 matching lines, got %d", result.Count)
+	}
+	var numbers []int
+	for _, line := range result.Lines {
+		numbers = append(numbers, line.Number)
+	}
+	want := []int{1, 2, 3, 4, 5, 7, 8}
+	if len(numbers) != len(want) {
+		t.Fatalf("expected lines %v, got %v", want, numbers)
+	}
+	for i := range want {
+		if numbers[i] != want[i] {
+			t.Fatalf("expected lines %v, got %v", want, numbers)
+		}
+	}
+	last := result.Lines[len(result.Lines)-1]
+	if !last.IsMatch() || last.Column != 3 || last.Text != "h x" {
+		t.Fatalf("unexpected last line: %+v", last)
+	}
+}
+
+// TestSearchContentFilesWithMatches ensures only the first match is kept when
+// FilesWithMatches is set.
+func TestSearchContentFilesWithMatches(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	if err := os.WriteFile(filepath.Join(td, "a.txt"), []byte("hit\nhit\nhit\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	results, err := SearchContent(context.Background(), "hit", td, SearchOptions{})
+	if err != nil {
+		t.Fatalf("SearchContent returned error: %v", err)
+	}
+	if len(results) != 1 || results[0].Count != 3 {
+		t.Fatalf("expected 3 matches in one file, got %+v", results)
+	}
+
+	results, err = SearchContent(context.Background(), "hit", td, SearchOptions{FilesWithMatches: true})
+	if err != nil {
+		t.Fatalf("SearchContent returned error: %v", err)
+	}
+	if len(results) != 1 || results[0].Count != 1 {
+		t.Fatalf("expected scanning to stop at the first match, got %+v", results)
+	}
+}
+
+// countingReader counts how many bytes have been read through it.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+// TestScanContentLongLines ensures matches spanning buffer boundaries in lines
+// longer than the read buffer are found with the correct column.
+func TestScanContentLongLines(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewMatcher("needle", MatchOptions{})
+	if err != nil {
+		t.Fatalf("NewMatcher returned error: %v", err)
+	}
+	// Place the match across the first buffer boundary of a very long line
+	start := scanBufferSize - 3
+	long := strings.Repeat("a", start) + "needle" + strings.Repeat("b", 3*scanBufferSize)
+	content := "short\n" + long + "\nlast needle\n"
+
+	var matches []ContentLine
+	err = scanContent(strings.NewReader(content), m, SearchOptions{}, func(line ContentLine) error {
+		matches = append(matches, line)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("scanContent returned error: %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 matching lines, got %d", len(matches))
+	}
+	if matches[0].Number != 2 || matches[0].Column != start+1 {
+		t.Fatalf("unexpected long line match: number %d column %d", matches[0].Number, matches[0].Column)
+	}
+	if len(matches[0].Text) > scanBufferSize+scanOverlap {
+		t.Fatalf("long line text was not bounded: %d bytes", len(matches[0].Text))
+	}
+	if matches[1].Number != 3 || matches[1].Text != "last needle" {
+		t.Fatalf("unexpected match after long line: %+v", matches[1])
+	}
+}
+
+// TestScanContentStopsEarly ensures FilesWithMatches stops reading after the first match.
+func TestScanContentStopsEarly(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewMatcher("hit", MatchOptions{})
+	if err != nil {
+		t.Fatalf("NewMatcher returned error: %v", err)
+	}
+	content := "hit\n" + strings.Repeat("filler line\n", 100000)
+	reader := &countingReader{r: strings.NewReader(content)}
+
+	err = scanContent(reader, m, SearchOptions{FilesWithMatches: true}, func(line ContentLine) error { return nil })
+	if err != nil {
+		t.Fatalf("scanContent returned error: %v", err)
+	}
+	if reader.n >= len(content) {
+		t.Fatalf("expected scanning to stop early, read %d of %d bytes", reader.n, len(content))
+	}
+}
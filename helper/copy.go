@@ -1,17 +1,36 @@
 package helper
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
+// copyTemps holds the temporary files of the copies in progress.
+var copyTemps sync.Map
+
+// RemoveTempFiles removes the temporary files of the copies in progress. It is
+// meant for aborting the process, where the copies won't get to clean up.
+func RemoveTempFiles() {
+	copyTemps.Range(func(path, _ any) bool {
+		_ = os.Remove(path.(string))
+		return true
+	})
+}
+
 // CopyFile copies a single file from src to dst. If removeSource is true, the source file is deleted after copying.
 // If overwrite is true, the destination file is overwritten if it already exists.
+// The data is written to a temporary file next to the destination, which is
+// renamed into place once complete, so a failed copy never leaves a partial file.
 func CopyFile(src, dst string, removeSource bool, overwrite bool) error {
 	_, filename := filepath.Split(src)
+	target := filepath.Join(dst, filename)
 	sourceFile, err := os.Open(src)
 	if err != nil {
 		return err
@@ -19,21 +38,41 @@ func CopyFile(src, dst string, removeSource bool, overwrite bool) error {
 	defer sourceFile.Close()
 
 	if !overwrite {
-		if _, err := os.Stat(filepath.Join(dst, filename)); !os.IsNotExist(err) {
-			if !overwrite {
-				return fmt.Errorf("file already exists: %s", dst)
-			}
+		if _, err := os.Stat(target); !os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s", fs.ErrExist, dst)
 		}
 	}
 
-	destinationFile, err := os.Create(filepath.Join(dst, filename))
+	info, err := sourceFile.Stat()
 	if err != nil {
 		return err
 	}
-	defer destinationFile.Close()
+	tempFile, err := os.CreateTemp(dst, ".f-copy-*")
+	if err != nil {
+		return err
+	}
+	copyTemps.Store(tempFile.Name(), nil)
+	defer copyTemps.Delete(tempFile.Name())
 
-	_, err = io.Copy(destinationFile, sourceFile)
+	_, err = io.Copy(countingWriter{w: tempFile, n: &bytesWritten}, sourceFile)
+	if err == nil {
+		err = tempFile.Chmod(info.Mode().Perm())
+	}
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		if overwrite {
+			err = os.Rename(tempFile.Name(), target)
+		} else {
+			err = renameNoReplace(tempFile.Name(), target)
+			if errors.Is(err, fs.ErrExist) {
+				err = fmt.Errorf("%w: %s", fs.ErrExist, dst)
+			}
+		}
+	}
 	if err != nil {
+		_ = os.Remove(tempFile.Name())
 		return err
 	}
 
@@ -49,11 +88,26 @@ func CopyFile(src, dst string, removeSource bool, overwrite bool) error {
 
 // CopyDirectory copies a directory from src to dst. If removeSource is true, the source directory is deleted after copying.
 // If overwrite is true, the destination files are overwritten if they already exist.
-func CopyDirectory(src, dst string, removeSource bool, overwrite bool) error {
+// Once ctx is cancelled no more files are started: a copy removes what it has
+// created so far, while a move leaves the files already moved at dst.
+func CopyDirectory(ctx context.Context, src, dst string, removeSource bool, overwrite bool) error {
 	src = strings.TrimSuffix(src, string(os.PathSeparator))
 	dst = filepath.Join(dst, filepath.Base(src))
 
-	err := os.MkdirAll(dst, os.ModePerm)
+	// created are the files and directories made by this copy, in order
+	var created []string
+	mkdir := func(dir string, mode os.FileMode) error {
+		if _, err := os.Lstat(dir); err == nil {
+			return nil
+		}
+		if err := os.MkdirAll(dir, mode); err != nil {
+			return err
+		}
+		created = append(created, dir)
+		return nil
+	}
+
+	err := mkdir(dst, os.ModePerm)
 	if err != nil {
 		return err
 	}
@@ -64,12 +118,15 @@ func CopyDirectory(src, dst string, removeSource bool, overwrite bool) error {
 		if err != nil {
 			return err
 		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
 		relPath := strings.TrimPrefix(path, src)
 		targetPath := filepath.Join(dst, relPath)
 
 		if info.IsDir() {
-			err := os.MkdirAll(targetPath, info.Mode())
+			err := mkdir(targetPath, info.Mode())
 			if err != nil {
 				return err
 			}
@@ -77,15 +134,26 @@ func CopyDirectory(src, dst string, removeSource bool, overwrite bool) error {
 			return nil
 		}
 
+		_, statErr := os.Lstat(targetPath)
+		existed := statErr == nil
 		err = CopyFile(path, filepath.Dir(targetPath), removeSource, overwrite)
 		if err != nil {
 			return err
 		}
+		// an overwritten file isn't ours to remove on rollback
+		if !existed {
+			created = append(created, targetPath)
+		}
 
 		return nil
 	})
 
 	if walkErr != nil {
+		if ctx.Err() != nil && !removeSource {
+			for i := len(created) - 1; i >= 0; i-- {
+				_ = os.Remove(created[i])
+			}
+		}
 		return walkErr
 	}
 
@@ -98,32 +166,35 @@ func CopyDirectory(src, dst string, removeSource bool, overwrite bool) error {
 	return nil
 }
 
-// Copy handles copying files, directories, and wildcards.
-func Copy(src, dst string, removeSource bool, overwrite bool) error {
+// Copy handles copying files, directories, and wildcards, working on jobs
+// matches at once. If some matches fail, the rest are still copied and a
+// *BatchError is returned.
+func Copy(ctx context.Context, src, dst string, removeSource bool, overwrite bool, jobs int) error {
 	matches, err := filepath.Glob(src)
 	if err != nil {
 		return err
 	}
+	return CopyPaths(ctx, matches, dst, removeSource, overwrite, jobs)
+}
 
-	err = os.MkdirAll(dst, os.ModePerm)
-	if err != nil {
+// CopyPaths copies, or with removeSource moves, each of paths into dst
+// concurrently with jobs workers, or with AutoJobs as many as suit the storage
+// of paths and dst. Unlike Copy, the paths are used as they are rather than
+// expanded as wildcards. If some paths fail, the rest are still copied and a
+// *BatchError listing the failures is returned. Once ctx is cancelled no new
+// paths are started, files being copied are finished and directories being
+// copied are rolled back.
+func CopyPaths(ctx context.Context, paths []string, dst string, removeSource bool, overwrite bool, jobs int) error {
+	if err := os.MkdirAll(dst, os.ModePerm); err != nil {
 		return err
 	}
 
 	run := func(info os.FileInfo, match string) error {
 		if info.IsDir() {
-			err = CopyDirectory(match, dst, removeSource, overwrite)
-		} else {
-			err = CopyFile(match, dst, removeSource, overwrite)
+			return CopyDirectory(ctx, match, dst, removeSource, overwrite)
 		}
-		return err
+		return CopyFile(match, dst, removeSource, overwrite)
 	}
 
-	err = RunConcurrent(run, 4, matches)
-
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return runConcurrent(ctx, run, jobs, paths, append([]string{dst}, paths...))
 }
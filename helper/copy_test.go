@@ -1,6 +1,9 @@
 package helper
 
 import (
+	"context"
+	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"testing"
@@ -118,7 +121,7 @@ func TestCopyDirectory_BasicAndRemoveSource(t *testing.T) {
 	}
 
 	// copy directory (without removing source)
-	if err := CopyDirectory(srcRoot, dstParent, false, false); err != nil {
+	if err := CopyDirectory(context.Background(), srcRoot, dstParent, false, false); err != nil {
 		t.Fatalf("CopyDirectory failed: %v", err)
 	}
 
@@ -154,7 +157,7 @@ func TestCopyDirectory_BasicAndRemoveSource(t *testing.T) {
 		t.Fatalf("mkdir dstparent2: %v", err)
 	}
 
-	if err := CopyDirectory(srcRoot2, dstParent2, true, false); err != nil {
+	if err := CopyDirectory(context.Background(), srcRoot2, dstParent2, true, false); err != nil {
 		t.Fatalf("CopyDirectory with removeSource failed: %v", err)
 	}
 	// after removal, srcRoot2 should either not exist or be empty; expect it to not exist
@@ -194,7 +197,7 @@ func TestCopy_WildcardFilesAndDirectories(t *testing.T) {
 	}
 
 	pattern := filepath.Join(srcParent, "*")
-	if err := Copy(pattern, dst, false, false); err != nil {
+	if err := Copy(context.Background(), pattern, dst, false, false, AutoJobs); err != nil {
 		t.Fatalf("Copy with wildcard failed: %v", err)
 	}
 
@@ -218,3 +221,138 @@ func TestCopy_WildcardFilesAndDirectories(t *testing.T) {
 		t.Fatalf("copied subfile content mismatch: %q", string(data))
 	}
 }
+
+// TestCopyFile_NoTempFilesLeft verifies that copies go through a temporary
+// file that never outlives the copy, even when it fails.
+func TestCopyFile_NoTempFilesLeft(t *testing.T) {
+	t.Parallel()
+
+	tdir := t.TempDir()
+	src := filepath.Join(tdir, "a.txt")
+	dst := filepath.Join(tdir, "dst")
+	if err := os.WriteFile(src, []byte("new"), 0o640); err != nil {
+		t.Fatalf("write source: %v", err)
+	}
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		t.Fatalf("mkdir dst: %v", err)
+	}
+
+	if err := CopyFile(src, dst, false, false); err != nil {
+		t.Fatalf("CopyFile failed: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dst, "a.txt"))
+	if err != nil || info.Mode().Perm() != 0o640 {
+		t.Fatalf("expected the copy to keep the source permissions, got %v, %v", info, err)
+	}
+	if err := CopyFile(src, dst, false, false); !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("expected an exists error, got %v", err)
+	}
+	entries, _ := os.ReadDir(dst)
+	if len(entries) != 1 {
+		t.Fatalf("expected only a.txt in the destination, got %v", entries)
+	}
+}
+
+// TestCopyDirectory_CancelRollsBack verifies that an interrupted directory
+// copy removes what it created.
+func TestCopyDirectory_CancelRollsBack(t *testing.T) {
+	t.Parallel()
+
+	tdir := t.TempDir()
+	src := filepath.Join(tdir, "src")
+	dst := filepath.Join(tdir, "dst")
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0o755); err != nil {
+		t.Fatalf("mkdir src: %v", err)
+	}
+	for _, name := range []string{"a.txt", "sub/b.txt"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(name), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := CopyDirectory(ctx, src, dst, false, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the copy to be cancelled, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(dst, "src")); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected the partial copy to be removed, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(src, "sub", "b.txt")); err != nil {
+		t.Fatalf("expected the source to be untouched: %v", err)
+	}
+}
+
+// TestRemoveTempFiles verifies that the temporary files of copies in progress
+// can be removed when aborting.
+func TestRemoveTempFiles(t *testing.T) {
+	t.Parallel()
+
+	temp := filepath.Join(t.TempDir(), ".f-copy-test")
+	if err := os.WriteFile(temp, nil, 0o644); err != nil {
+		t.Fatalf("write temp: %v", err)
+	}
+	copyTemps.Store(temp, nil)
+	defer copyTemps.Delete(temp)
+
+	RemoveTempFiles()
+	if _, err := os.Lstat(temp); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected the temporary file to be removed, got %v", err)
+	}
+}
+
+// cancelAfter is a context that reports itself cancelled from the n-th call
+// to Err on, so a test can stop a walk part way through.
+type cancelAfter struct {
+	context.Context
+	n     int
+	calls int
+}
+
+func (c *cancelAfter) Err() error {
+	c.calls++
+	if c.calls >= c.n {
+		return context.Canceled
+	}
+	return nil
+}
+
+// TestCopyDirectory_CancelKeepsOverwrittenFiles verifies that rolling back an
+// interrupted overwriting copy removes only the files it added, not the ones
+// that were already at the destination.
+func TestCopyDirectory_CancelKeepsOverwrittenFiles(t *testing.T) {
+	t.Parallel()
+
+	tdir := t.TempDir()
+	src := filepath.Join(tdir, "src")
+	dst := filepath.Join(tdir, "dst")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatalf("mkdir src: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dst, "src"), 0o755); err != nil {
+		t.Fatalf("mkdir dst: %v", err)
+	}
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte("new"), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	existing := filepath.Join(dst, "src", "a.txt")
+	if err := os.WriteFile(existing, []byte("old"), 0o644); err != nil {
+		t.Fatalf("write existing: %v", err)
+	}
+
+	// the walk visits src, a.txt and b.txt, then stops before c.txt
+	ctx := &cancelAfter{Context: context.Background(), n: 4}
+	if err := CopyDirectory(ctx, src, dst, false, true); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the copy to be cancelled, got %v", err)
+	}
+	if _, err := os.Stat(existing); err != nil {
+		t.Fatalf("expected the overwritten file to be kept: %v", err)
+	}
+	for _, name := range []string{"b.txt", "c.txt"} {
+		if _, err := os.Lstat(filepath.Join(dst, "src", name)); !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("expected %s to be rolled back, got %v", name, err)
+		}
+	}
+}
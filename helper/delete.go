@@ -2,11 +2,16 @@ package helper
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 )
 
+// ErrCancelled is returned when the user declines a confirmation.
+var ErrCancelled = errors.New("cancelled")
+
 // DeleteFile deletes a file from src.
 func DeleteFile(src string) error {
 	err := os.Remove(src)
@@ -26,43 +31,66 @@ func DeleteDirectory(src string) error {
 }
 
 // Delete deletes a file or directory from src. If force is true, it will delete without confirmation.
-func Delete(src string, force bool) error {
+// Forced deletions work on jobs matches at once.
+// If some matches fail, the rest are still deleted and a *BatchError is returned.
+func Delete(ctx context.Context, src string, force bool, jobs int) error {
 	matches, err := filepath.Glob(src)
 	if err != nil {
 		return err
 	}
+	return DeletePaths(ctx, matches, force, jobs)
+}
 
-	run := func(info os.FileInfo, match string) error {
-		var input string
+// DeletePaths deletes each of paths, asking for confirmation of each unless
+// force is true. Forced deletions run with jobs workers, or with AutoJobs as
+// many as suit the storage of paths. Unlike Delete, the paths are used as they are rather than
+// expanded as wildcards. If some paths fail or are declined, the rest are
+// still deleted and a *BatchError listing the failures is returned. Once ctx
+// is cancelled no new paths are started and a pending confirmation is dropped.
+func DeletePaths(ctx context.Context, paths []string, force bool, jobs int) error {
+	// Prompts are asked one at a time so their answers can't get mixed up
+	workers := jobs
+	if !force {
+		workers = 1
+	}
+	reader := bufio.NewReader(os.Stdin)
 
+	run := func(info os.FileInfo, match string) error {
 		if !force {
-			reader := bufio.NewReader(os.Stdin)
-
 			fmt.Printf("Are you sure you want to delete %s? (y/n): ", match)
-			input, err = reader.ReadString('\n')
+			input, err := readLine(ctx, reader)
 			if err != nil {
-				fmt.Println("Error reading input:", err)
-				return err
+				return fmt.Errorf("reading input: %w", err)
 			}
-		}
-
-		if input == "y\n" || input == "Y\n" || force {
-			if info.IsDir() {
-				err = DeleteDirectory(match)
-			} else {
-				err = DeleteFile(match)
+			if input != "y\n" && input != "Y\n" {
+				return fmt.Errorf("deletion of %s %w", match, ErrCancelled)
 			}
-		} else {
-			return fmt.Errorf("deletion of %s cancelled", match)
 		}
-		return err
+		if info.IsDir() {
+			return DeleteDirectory(match)
+		}
+		return DeleteFile(match)
 	}
 
-	err = RunConcurrent(run, 4, matches)
+	return RunConcurrent(ctx, run, workers, paths)
+}
 
-	if err != nil {
-		return err
+// readLine reads a line from reader, giving up when ctx is cancelled. The read
+// itself can't be interrupted, so it is left running in the background.
+func readLine(ctx context.Context, reader *bufio.Reader) (string, error) {
+	type line struct {
+		text string
+		err  error
+	}
+	done := make(chan line, 1)
+	go func() {
+		text, err := reader.ReadString('\n')
+		done <- line{text, err}
+	}()
+	select {
+	case l := <-done:
+		return l.text, l.err
+	case <-ctx.Done():
+		return "", ctx.Err()
 	}
-
-	return nil
 }
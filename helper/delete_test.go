@@ -1,6 +1,7 @@
 package helper
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -70,7 +71,7 @@ func TestDelete_ForceDeletesMatches(t *testing.T) {
 	}
 
 	pattern := filepath.Join(td, "*")
-	if err := Delete(pattern, true); err != nil {
+	if err := Delete(context.Background(), pattern, true, 2); err != nil {
 		t.Fatalf("Delete with force returned error: %v", err)
 	}
 
@@ -109,7 +110,7 @@ func TestDelete_ConfirmYesAndNo(t *testing.T) {
 	_ = w1.Close()
 
 	os.Stdin = r1
-	if err := Delete(f, false); err == nil {
+	if err := Delete(context.Background(), f, false, AutoJobs); err == nil {
 		t.Fatalf("expected deletion to be cancelled (error), got nil")
 	}
 	// file should still exist
@@ -132,7 +133,7 @@ func TestDelete_ConfirmYesAndNo(t *testing.T) {
 	_ = w2.Close()
 
 	os.Stdin = r2
-	if err := Delete(f, false); err != nil {
+	if err := Delete(context.Background(), f, false, AutoJobs); err != nil {
 		t.Fatalf("expected deletion to proceed after 'y', got error: %v", err)
 	}
 	if _, err := os.Stat(f); !os.IsNotExist(err) {
@@ -0,0 +1,156 @@
+package helper
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// UsageEntry is the disk usage of a file, or the cumulative usage of a directory.
+type UsageEntry struct {
+	Path      string
+	IsDir     bool
+	Depth     int
+	Apparent  int64
+	Allocated int64
+}
+
+// UsageReport holds the usage of every entry found under a root directory.
+// The first entry is the root itself, and the rest are sorted by path.
+type UsageReport struct {
+	Entries []UsageEntry
+}
+
+// Root returns the entry for the walked directory.
+func (r *UsageReport) Root() UsageEntry {
+	return r.Entries[0]
+}
+
+// Directories returns the directories at most maxDepth levels below the root.
+// A negative maxDepth returns every directory.
+func (r *UsageReport) Directories(maxDepth int) []UsageEntry {
+	var dirs []UsageEntry
+	for _, entry := range r.Entries {
+		if entry.IsDir && (maxDepth < 0 || entry.Depth <= maxDepth) {
+			dirs = append(dirs, entry)
+		}
+	}
+	return dirs
+}
+
+// Largest returns the n largest files and directories below the root, ordered
+// by allocated size, or by apparent size if apparent is true.
+func (r *UsageReport) Largest(n int, apparent bool) []UsageEntry {
+	entries := append([]UsageEntry(nil), r.Entries[1:]...)
+	SortUsage(entries, apparent)
+	if n < len(entries) {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// SortUsage orders entries from largest to smallest, breaking ties by path.
+func SortUsage(entries []UsageEntry, apparent bool) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i].Size(apparent), entries[j].Size(apparent)
+		if a != b {
+			return a > b
+		}
+		return entries[i].Path < entries[j].Path
+	})
+}
+
+// Size returns the apparent or allocated size of the entry.
+func (e UsageEntry) Size(apparent bool) int64 {
+	if apparent {
+		return e.Apparent
+	}
+	return e.Allocated
+}
+
+// DiskUsage walks root with threads workers and returns the apparent and
+// allocated size of every file and the cumulative sizes of every directory.
+// The root comes first and the other entries are sorted by path. Hard-linked
+// files are only counted once, like du.
+// Entries that can't be read are left out and reported in a *WalkError
+// returned alongside the rest of the report. If ctx is cancelled the walk
+// stops and ctx's error is returned.
+func DiskUsage(ctx context.Context, root string, threads int) (*UsageReport, error) {
+	root = filepath.Clean(root)
+	report := &UsageReport{}
+	var mu sync.Mutex
+	dirIndex := map[string]int{}
+	seen := map[fileKey]bool{}
+	var failures walkErrors
+
+	err := Walk(root, threads, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			failures.add(path, err)
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			failures.add(path, err)
+			return nil
+		}
+
+		depth := 0
+		if rel, err := filepath.Rel(root, path); err == nil && rel != "." {
+			depth = strings.Count(rel, string(filepath.Separator)) + 1
+		}
+		entry := UsageEntry{Path: path, IsDir: d.IsDir(), Depth: depth}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if d.IsDir() {
+			// A directory's own blocks count towards it, as in du
+			dirIndex[path] = len(report.Entries)
+			entry.Allocated = allocatedSize(info)
+		} else if key, ok := fileID(info); !ok || !seen[key] {
+			if ok {
+				seen[key] = true
+			}
+			entry.Apparent = info.Size()
+			entry.Allocated = allocatedSize(info)
+		}
+		report.Entries = append(report.Entries, entry)
+
+		// Add the sizes to every enclosing directory up to the root. Directories
+		// are always visited before their contents, so they are already indexed.
+		if path != root {
+			for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+				if i, ok := dirIndex[dir]; ok {
+					report.Entries[i].Apparent += entry.Apparent
+					report.Entries[i].Allocated += entry.Allocated
+				}
+				if dir == root || dir == filepath.Dir(dir) {
+					break
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The root is pinned first: below "." paths have no "./" prefix, so a name
+	// like "-big" would otherwise sort before it
+	sort.Slice(report.Entries, func(i, j int) bool {
+		a, b := report.Entries[i].Path, report.Entries[j].Path
+		if a == root || b == root {
+			return a == root && b != root
+		}
+		return a < b
+	})
+	return report, failures.err()
+}
@@ -0,0 +1,21 @@
+//go:build !unix
+
+package helper
+
+import "io/fs"
+
+// fileKey identifies a file by device and inode.
+type fileKey struct {
+	dev uint64
+	ino uint64
+}
+
+// fileID is unsupported on this platform, so hard links are counted each time.
+func fileID(info fs.FileInfo) (fileKey, bool) {
+	return fileKey{}, false
+}
+
+// allocatedSize falls back to the apparent size where st_blocks is unavailable.
+func allocatedSize(info fs.FileInfo) int64 {
+	return info.Size()
+}
@@ -0,0 +1,117 @@
+package helper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestDiskUsage verifies cumulative directory sizes, depth filtering and the largest-entry list.
+func TestDiskUsage(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	// td/
+	//   small.txt (10 bytes)
+	//   a/big.bin (1000 bytes)
+	//   a/b/mid.bin (100 bytes)
+	if err := os.MkdirAll(filepath.Join(td, "a", "b"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	files := map[string]int{"small.txt": 10, "a/big.bin": 1000, "a/b/mid.bin": 100}
+	for rel, size := range files {
+		if err := os.WriteFile(filepath.Join(td, rel), make([]byte, size), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+
+	report, err := DiskUsage(context.Background(), td, 0)
+	if err != nil {
+		t.Fatalf("DiskUsage returned error: %v", err)
+	}
+	if got := report.Root().Apparent; got != 1110 {
+		t.Fatalf("expected root apparent size 1110, got %d", got)
+	}
+
+	dirs := report.Directories(1)
+	if len(dirs) != 2 {
+		t.Fatalf("expected root and a at depth 1, got %v", dirs)
+	}
+	for _, d := range dirs {
+		if filepath.Base(d.Path) == "a" && d.Apparent != 1100 {
+			t.Fatalf("expected a to total 1100 bytes, got %d", d.Apparent)
+		}
+	}
+	if all := report.Directories(-1); len(all) != 3 {
+		t.Fatalf("expected 3 directories with unlimited depth, got %d", len(all))
+	}
+
+	// The allocated total includes the blocks of the directories themselves
+	var allocated int64
+	for _, rel := range []string{".", "a", "a/b", "small.txt", "a/big.bin", "a/b/mid.bin"} {
+		info, err := os.Lstat(filepath.Join(td, rel))
+		if err != nil {
+			t.Fatalf("lstat %s: %v", rel, err)
+		}
+		allocated += allocatedSize(info)
+	}
+	if got := report.Root().Allocated; got != allocated {
+		t.Fatalf("expected root allocated size %d, got %d", allocated, got)
+	}
+
+	largest := report.Largest(2, true)
+	if len(largest) != 2 || filepath.Base(largest[0].Path) != "a" || filepath.Base(largest[1].Path) != "big.bin" {
+		t.Fatalf("unexpected largest entries: %v", largest)
+	}
+}
+
+// TestDiskUsageHardLinks ensures hard-linked files are only counted once.
+func TestDiskUsageHardLinks(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	orig := filepath.Join(td, "orig.bin")
+	if err := os.WriteFile(orig, make([]byte, 500), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.Link(orig, filepath.Join(td, "link.bin")); err != nil {
+		t.Skipf("hard links not supported: %v", err)
+	}
+
+	report, err := DiskUsage(context.Background(), td, 0)
+	if err != nil {
+		t.Fatalf("DiskUsage returned error: %v", err)
+	}
+	if got := report.Root().Apparent; got != 500 {
+		t.Fatalf("expected hard link to be counted once (500 bytes), got %d", got)
+	}
+}
+
+// TestDiskUsageDotRoot ensures the root stays first when walking "." with a
+// child whose name sorts before it.
+func TestDiskUsageDotRoot(t *testing.T) {
+	td := t.TempDir()
+	if err := os.WriteFile(filepath.Join(td, "-big"), make([]byte, 100), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	if err := os.Chdir(td); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	defer os.Chdir(wd)
+
+	report, err := DiskUsage(context.Background(), ".", 0)
+	if err != nil {
+		t.Fatalf("DiskUsage returned error: %v", err)
+	}
+	if root := report.Root(); root.Path != "." || !root.IsDir || root.Apparent != 100 {
+		t.Fatalf("expected the root first with 100 bytes, got %+v", root)
+	}
+	if len(report.Entries) != 2 || report.Entries[1].Path != "-big" {
+		t.Fatalf("expected -big after the root, got %+v", report.Entries)
+	}
+}
@@ -0,0 +1,32 @@
+//go:build unix
+
+package helper
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileKey identifies a file by device and inode.
+type fileKey struct {
+	dev uint64
+	ino uint64
+}
+
+// fileID returns the device and inode of a file, so hard links can be detected.
+func fileID(info fs.FileInfo) (fileKey, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || stat.Nlink < 2 {
+		return fileKey{}, false
+	}
+	return fileKey{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, true
+}
+
+// allocatedSize returns the space a file occupies on disk, from st_blocks.
+func allocatedSize(info fs.FileInfo) int64 {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info.Size()
+	}
+	return int64(stat.Blocks) * 512
+}
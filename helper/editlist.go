@@ -0,0 +1,61 @@
+package helper
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// editListHeader explains the format at the top of the file opened in the editor.
+const editListHeader = `# Edit the paths below to rename or move files, then save and quit.
+# Keep the number in front of each path. Removed lines are deleted only with --delete.
+# Lines starting with # are ignored.
+`
+
+// FormatEditList writes paths as numbered lines for editing.
+func FormatEditList(paths []string) (string, error) {
+	var b strings.Builder
+	b.WriteString(editListHeader)
+	for i, path := range paths {
+		if strings.ContainsAny(path, "\n\r") {
+			return "", fmt.Errorf("can't edit a path containing a line break: %q", path)
+		}
+		fmt.Fprintf(&b, "%d\t%s\n", i+1, path)
+	}
+	return b.String(), nil
+}
+
+// ParseEditList compares an edited list written by FormatEditList with the
+// original paths. It returns a rename for each changed path, and the paths
+// whose lines were removed.
+func ParseEditList(text string, paths []string) (renames []RenameOp, removed []string, err error) {
+	seen := make([]bool, len(paths))
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		number, path, ok := strings.Cut(line, "\t")
+		n, convErr := strconv.Atoi(strings.TrimSpace(number))
+		if !ok || convErr != nil || n < 1 || n > len(paths) {
+			return nil, nil, fmt.Errorf("line %d: expected a number from the original list, a tab and a path: %q", i+1, line)
+		}
+		if seen[n-1] {
+			return nil, nil, fmt.Errorf("line %d: number %d appears more than once", i+1, n)
+		}
+		seen[n-1] = true
+		if path == "" {
+			return nil, nil, fmt.Errorf("line %d: empty path for %s", i+1, paths[n-1])
+		}
+		if filepath.Clean(path) != filepath.Clean(paths[n-1]) {
+			renames = append(renames, RenameOp{From: paths[n-1], To: path})
+		}
+	}
+	for i, path := range paths {
+		if !seen[i] {
+			removed = append(removed, path)
+		}
+	}
+	return renames, removed, nil
+}
@@ -0,0 +1,43 @@
+package helper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseEditList checks renames, removed lines and malformed input.
+func TestParseEditList(t *testing.T) {
+	t.Parallel()
+
+	paths := []string{"a.txt", "b.txt", "c.txt", "d.txt"}
+	list, err := FormatEditList(paths)
+	if err != nil {
+		t.Fatalf("FormatEditList returned error: %v", err)
+	}
+	if !strings.Contains(list, "1\ta.txt\n") || !strings.HasPrefix(list, "#") {
+		t.Fatalf("unexpected list:\n%s", list)
+	}
+
+	edited := strings.NewReplacer("1\ta.txt", "1\tb.txt", "2\tb.txt", "2\ta.txt", "3\tc.txt\n", "", "4\td.txt", "4\tsub/d.txt").Replace(list)
+	renames, removed, err := ParseEditList(edited, paths)
+	if err != nil {
+		t.Fatalf("ParseEditList returned error: %v", err)
+	}
+	want := []RenameOp{{"a.txt", "b.txt"}, {"b.txt", "a.txt"}, {"d.txt", "sub/d.txt"}}
+	if !reflect.DeepEqual(renames, want) {
+		t.Fatalf("renames = %v, want %v", renames, want)
+	}
+	if !reflect.DeepEqual(removed, []string{"c.txt"}) {
+		t.Fatalf("removed = %v, want [c.txt]", removed)
+	}
+
+	for _, bad := range []string{"1 a.txt", "9\tx", "1\tx\n1\ty", "x\ty", "1\t"} {
+		if _, _, err := ParseEditList(bad, paths); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+	if _, err := FormatEditList([]string{"bad\nname"}); err == nil {
+		t.Fatalf("expected error for a path with a line break")
+	}
+}
@@ -0,0 +1,205 @@
+package helper
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// binaryCheckSize is how much of the start of a file is inspected to decide whether it is binary.
+const binaryCheckSize = 8 * 1024
+
+// BinaryMode selects how content search treats binary files.
+type BinaryMode int
+
+const (
+	// BinarySkip ignores binary files.
+	BinarySkip BinaryMode = iota
+	// BinaryMatchOnly reports that a binary file matches without printing lines.
+	BinaryMatchOnly
+	// BinaryText searches binary files as if they were text.
+	BinaryText
+)
+
+// ParseBinaryMode parses the value of the --binary flag.
+func ParseBinaryMode(s string) (BinaryMode, error) {
+	switch s {
+	case "", "skip":
+		return BinarySkip, nil
+	case "match-only":
+		return BinaryMatchOnly, nil
+	case "text":
+		return BinaryText, nil
+	}
+	return BinarySkip, fmt.Errorf("invalid binary mode %q (expected skip, match-only or text)", s)
+}
+
+// Encoding is the character encoding content search decodes files from.
+type Encoding int
+
+const (
+	// EncodingAuto decodes UTF-16 files with a byte order mark and reads everything else as UTF-8.
+	EncodingAuto Encoding = iota
+	EncodingUTF8
+	EncodingLatin1
+	EncodingUTF16LE
+	EncodingUTF16BE
+)
+
+// ParseEncoding parses the value of the --encoding flag.
+func ParseEncoding(s string) (Encoding, error) {
+	switch strings.ToLower(s) {
+	case "", "auto":
+		return EncodingAuto, nil
+	case "utf-8", "utf8":
+		return EncodingUTF8, nil
+	case "latin1", "latin-1", "iso-8859-1":
+		return EncodingLatin1, nil
+	case "utf-16le", "utf16le":
+		return EncodingUTF16LE, nil
+	case "utf-16be", "utf16be":
+		return EncodingUTF16BE, nil
+	}
+	return EncodingAuto, fmt.Errorf("invalid encoding %q (expected auto, utf-8, latin1, utf-16le or utf-16be)", s)
+}
+
+// decodeContent inspects the start of r and returns a reader producing UTF-8
+// text, along with whether the content looks binary. Byte order marks are
+// removed, and in auto mode they select the encoding.
+func decodeContent(r *bufio.Reader, encoding Encoding) (io.Reader, bool, error) {
+	head, err := r.Peek(binaryCheckSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, false, err
+	}
+
+	if encoding == EncodingAuto || encoding == EncodingUTF8 {
+		switch {
+		case bytes.HasPrefix(head, []byte{0xEF, 0xBB, 0xBF}):
+			_, _ = r.Discard(3)
+			head = head[3:]
+		case encoding == EncodingAuto && bytes.HasPrefix(head, []byte{0xFF, 0xFE}):
+			encoding = EncodingUTF16LE
+		case encoding == EncodingAuto && bytes.HasPrefix(head, []byte{0xFE, 0xFF}):
+			encoding = EncodingUTF16BE
+		}
+	}
+
+	switch encoding {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		if bytes.HasPrefix(head, []byte{0xFF, 0xFE}) || bytes.HasPrefix(head, []byte{0xFE, 0xFF}) {
+			_, _ = r.Discard(2)
+		}
+		return &utf16Reader{r: r, bigEndian: encoding == EncodingUTF16BE}, false, nil
+	case EncodingLatin1:
+		// Every byte is a valid Latin-1 character, so only NUL bytes mark binary data
+		return &latin1Reader{r: r}, bytes.IndexByte(head, 0) >= 0, nil
+	}
+	return r, isBinary(head), nil
+}
+
+// isBinary reports whether head, the start of a file, contains NUL bytes or invalid UTF-8.
+func isBinary(head []byte) bool {
+	if bytes.IndexByte(head, 0) >= 0 {
+		return true
+	}
+	// Ignore a multi-byte character cut off at the end of the block
+	for i := 0; i < utf8.UTFMax-1 && len(head) > 0 && !utf8.Valid(head); i++ {
+		head = head[:len(head)-1]
+	}
+	return !utf8.Valid(head)
+}
+
+// utf16Reader decodes UTF-16 into UTF-8. Code units are read a byte at a
+// time from a buffered reader. Unpaired surrogates decode to U+FFFD.
+type utf16Reader struct {
+	r         *bufio.Reader
+	bigEndian bool
+	pending   []byte
+	// held is a code unit read after a high surrogate that didn't pair with it
+	held    uint16
+	hasHeld bool
+}
+
+// readUnit reads one 16-bit code unit.
+func (u *utf16Reader) readUnit() (uint16, error) {
+	if u.hasHeld {
+		u.hasHeld = false
+		return u.held, nil
+	}
+	first, err := u.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	second, err := u.r.ReadByte()
+	if err != nil {
+		// A dangling byte can't form a code unit
+		return utf8.RuneError, nil
+	}
+	if u.bigEndian {
+		return uint16(first)<<8 | uint16(second), nil
+	}
+	return uint16(second)<<8 | uint16(first), nil
+}
+
+func (u *utf16Reader) Read(p []byte) (int, error) {
+	for len(u.pending) < len(p) {
+		unit, err := u.readUnit()
+		if err != nil {
+			if len(u.pending) > 0 {
+				break
+			}
+			return 0, err
+		}
+		r := rune(unit)
+		switch {
+		case r >= 0xD800 && r < 0xDC00:
+			// A high surrogate pairs with a following low one; anything else
+			// is decoded on its own on the next turn
+			r = utf8.RuneError
+			if low, err := u.readUnit(); err == nil {
+				if pair := utf16.DecodeRune(rune(unit), rune(low)); pair != utf8.RuneError {
+					r = pair
+				} else {
+					u.held, u.hasHeld = low, true
+				}
+			}
+		case utf16.IsSurrogate(r):
+			// A low surrogate without a high one before it
+			r = utf8.RuneError
+		}
+		u.pending = utf8.AppendRune(u.pending, r)
+	}
+	n := copy(p, u.pending)
+	u.pending = u.pending[n:]
+	return n, nil
+}
+
+// latin1Reader decodes ISO-8859-1 into UTF-8.
+type latin1Reader struct {
+	r       io.Reader
+	buf     []byte
+	pending []byte
+}
+
+func (l *latin1Reader) Read(p []byte) (int, error) {
+	if len(l.pending) == 0 {
+		if cap(l.buf) < len(p) {
+			l.buf = make([]byte, len(p))
+		}
+		n, err := l.r.Read(l.buf[:len(p)])
+		if n == 0 {
+			return 0, err
+		}
+		l.pending = l.pending[:0]
+		for _, b := range l.buf[:n] {
+			l.pending = utf8.AppendRune(l.pending, rune(b))
+		}
+	}
+	n := copy(p, l.pending)
+	l.pending = l.pending[n:]
+	return n, nil
+}
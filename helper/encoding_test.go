@@ -0,0 +1,122 @@
+package helper
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+)
+
+// TestIsBinary checks NUL detection, invalid UTF-8 and multi-byte characters cut at the block boundary.
+func TestIsBinary(t *testing.T) {
+	t.Parallel()
+
+	if isBinary([]byte("plain text\n")) {
+		t.Fatalf("plain text should not be binary")
+	}
+	if !isBinary([]byte("abc\x00def")) {
+		t.Fatalf("NUL bytes should mark content as binary")
+	}
+	if !isBinary([]byte{0xff, 0xfe, 0xfd, 'a', 'b'}) {
+		t.Fatalf("invalid UTF-8 should mark content as binary")
+	}
+	// "é" is 0xC3 0xA9; a block ending after the first byte is still text
+	if isBinary([]byte("caf\xc3")) {
+		t.Fatalf("truncated trailing character should not mark content as binary")
+	}
+}
+
+// encodeUTF16 encodes s as UTF-16 with a byte order mark.
+func encodeUTF16(s string, bigEndian bool) []byte {
+	var buf bytes.Buffer
+	units := append([]uint16{0xFEFF}, utf16.Encode([]rune(s))...)
+	for _, u := range units {
+		if bigEndian {
+			buf.Write([]byte{byte(u >> 8), byte(u)})
+		} else {
+			buf.Write([]byte{byte(u), byte(u >> 8)})
+		}
+	}
+	return buf.Bytes()
+}
+
+// TestDecodeContent verifies BOM detection for UTF-16 and explicit Latin-1 decoding.
+func TestDecodeContent(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		data     []byte
+		encoding Encoding
+		want     string
+	}{
+		{"utf16le", encodeUTF16("héllo 😀\n", false), EncodingAuto, "héllo 😀\n"},
+		{"utf16be", encodeUTF16("héllo\n", true), EncodingAuto, "héllo\n"},
+		// An unpaired high surrogate doesn't swallow the A after it, nor a lone low one the B
+		{"utf16 unpaired", []byte{0xFF, 0xFE, 0x00, 0xD8, 'A', 0, 0x00, 0xDC, 'B', 0}, EncodingAuto, "\uFFFDA\uFFFDB"},
+		{"utf16 high at end", []byte{0xFF, 0xFE, 'A', 0, 0x00, 0xD8}, EncodingAuto, "A\uFFFD"},
+		{"utf8 bom", []byte("\xEF\xBB\xBFtext"), EncodingAuto, "text"},
+		{"latin1", []byte("caf\xe9"), EncodingLatin1, "café"},
+	}
+	for _, c := range cases {
+		r, binary, err := decodeContent(bufio.NewReader(bytes.NewReader(c.data)), c.encoding)
+		if err != nil {
+			t.Fatalf("%s: decodeContent returned error: %v", c.name, err)
+		}
+		if binary {
+			t.Fatalf("%s: expected text, got binary", c.name)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("%s: read failed: %v", c.name, err)
+		}
+		if string(got) != c.want {
+			t.Fatalf("%s: decoded %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+// TestSearchContentEncodings ensures UTF-16 files are searched and binary files follow the binary mode.
+func TestSearchContentEncodings(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	if err := os.WriteFile(filepath.Join(td, "windows.txt"), encodeUTF16("first\r\nneedle here\r\n", false), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(td, "image.bin"), []byte("\x89PNG\x00\x00needle"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	results, err := SearchContent(context.Background(), "needle", td, SearchOptions{})
+	if err != nil {
+		t.Fatalf("SearchContent returned error: %v", err)
+	}
+	if len(results) != 1 || filepath.Base(results[0].Path) != "windows.txt" || results[0].Lines[0].Number != 2 {
+		t.Fatalf("expected only the UTF-16 file to match on line 2, got %+v", results)
+	}
+
+	results, err = SearchContent(context.Background(), "needle", td, SearchOptions{Binary: BinaryMatchOnly})
+	if err != nil {
+		t.Fatalf("SearchContent returned error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected the binary file to be reported too, got %+v", results)
+	}
+	for _, r := range results {
+		if filepath.Base(r.Path) == "image.bin" && (len(r.Lines) != 1 || !r.Lines[0].Binary) {
+			t.Fatalf("expected a single binary match for image.bin, got %+v", r.Lines)
+		}
+	}
+
+	if _, err := ParseBinaryMode("sometimes"); err == nil {
+		t.Fatalf("expected error for invalid binary mode")
+	}
+	if _, err := ParseEncoding("ebcdic"); err == nil {
+		t.Fatalf("expected error for invalid encoding")
+	}
+}
@@ -0,0 +1,206 @@
+package helper
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileType is a set of entry types a filter accepts.
+type FileType int
+
+const (
+	TypeFile FileType = 1 << iota
+	TypeDir
+	TypeSymlink
+	// TypeOther covers devices, sockets and named pipes.
+	TypeOther
+
+	TypeAny = TypeFile | TypeDir | TypeSymlink | TypeOther
+)
+
+// ParseFileType parses a comma-separated list of f (file), d (directory)
+// and l (symlink). An empty string accepts nothing, leaving the default to the caller.
+func ParseFileType(s string) (FileType, error) {
+	var types FileType
+	for _, part := range strings.Split(s, ",") {
+		switch strings.TrimSpace(part) {
+		case "":
+		case "f", "file":
+			types |= TypeFile
+		case "d", "dir", "directory":
+			types |= TypeDir
+		case "l", "symlink":
+			types |= TypeSymlink
+		default:
+			return 0, fmt.Errorf("invalid type %q (expected f, d or l)", part)
+		}
+	}
+	return types, nil
+}
+
+// entryType returns the type of d.
+func entryType(d fs.DirEntry) FileType {
+	switch mode := d.Type(); {
+	case mode.IsRegular():
+		return TypeFile
+	case mode.IsDir():
+		return TypeDir
+	case mode&fs.ModeSymlink != 0:
+		return TypeSymlink
+	}
+	return TypeOther
+}
+
+// FileFilter selects entries by metadata. The zero value accepts everything.
+type FileFilter struct {
+	// Types limits the entry types. Zero leaves the choice to the walk: searches
+	// visit everything except directories.
+	Types FileType
+	// MinSize and MaxSize bound the size of matched entries in bytes. Zero means no
+	// bound. Directories never match a size bound.
+	MinSize int64
+	MaxSize int64
+	// NewerThan and OlderThan bound the modification time. The zero time means no bound.
+	NewerThan time.Time
+	OlderThan time.Time
+	// MaxDepth stops the walk this many levels below the search directory, where
+	// its direct children are at depth 1. Zero means no limit.
+	MaxDepth int
+	// Empty only matches empty files and directories.
+	Empty bool
+	// Extensions only matches files with one of these extensions, given without
+	// the leading dot and compared regardless of case.
+	Extensions []string
+}
+
+// needsInfo reports whether matching requires the entry's file info.
+func (f FileFilter) needsInfo() bool {
+	return f.MinSize > 0 || f.MaxSize > 0 || !f.NewerThan.IsZero() || !f.OlderThan.IsZero() || f.Empty
+}
+
+// Match reports whether the entry d at path passes the filter.
+func (f FileFilter) Match(path string, d fs.DirEntry) (bool, error) {
+	if f.Types != 0 && f.Types&entryType(d) == 0 {
+		return false, nil
+	}
+	if len(f.Extensions) > 0 && !f.matchExtension(path) {
+		return false, nil
+	}
+	if !f.needsInfo() {
+		return true, nil
+	}
+
+	info, err := d.Info()
+	if err != nil {
+		return false, err
+	}
+	if (f.MinSize > 0 || f.MaxSize > 0) && info.IsDir() {
+		return false, nil
+	}
+	if f.MinSize > 0 && info.Size() < f.MinSize {
+		return false, nil
+	}
+	if f.MaxSize > 0 && info.Size() > f.MaxSize {
+		return false, nil
+	}
+	if !f.NewerThan.IsZero() && !info.ModTime().After(f.NewerThan) {
+		return false, nil
+	}
+	if !f.OlderThan.IsZero() && !info.ModTime().Before(f.OlderThan) {
+		return false, nil
+	}
+	if f.Empty {
+		return isEmpty(path, info)
+	}
+	return true, nil
+}
+
+// matchExtension reports whether path has one of the filter's extensions.
+func (f FileFilter) matchExtension(path string) bool {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	if ext == "" {
+		return false
+	}
+	for _, want := range f.Extensions {
+		if strings.EqualFold(ext, strings.TrimPrefix(want, ".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmpty reports whether a regular file has no content or a directory has no entries.
+func isEmpty(path string, info fs.FileInfo) (bool, error) {
+	if info.Mode().IsRegular() {
+		return info.Size() == 0, nil
+	}
+	if !info.IsDir() {
+		return false, nil
+	}
+	dir, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer dir.Close()
+	if _, err := dir.Readdirnames(1); err != io.EOF {
+		return false, err
+	}
+	return true, nil
+}
+
+// pathDepth returns how many levels path is below dir.
+func pathDepth(dir, path string) int {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+// timeLayouts are the absolute date formats accepted by ParseTime.
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ageUnits are the suffixes accepted by ParseTime for relative ages.
+var ageUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+	"y": 365 * 24 * time.Hour,
+}
+
+// ParseTime parses an age like "30m", "12h", "7d", "2w" or "1y" into the time
+// that long before now, or an absolute date like "2024-01-31" or
+// "2024-01-31 15:04" in the local time zone.
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	i := 0
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+		i++
+	}
+	unit, ok := ageUnits[strings.ToLower(s[i:])]
+	value, err := strconv.ParseFloat(s[:i], 64)
+	if !ok || err != nil || value < 0 {
+		return time.Time{}, fmt.Errorf("invalid time %q (expected an age like 7d or a date like 2006-01-02)", s)
+	}
+	return now.Add(-time.Duration(value * float64(unit))), nil
+}
@@ -0,0 +1,141 @@
+package helper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// TestParseTime checks relative ages, absolute dates and invalid input.
+func TestParseTime(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.Local)
+	cases := []struct {
+		in   string
+		want time.Time
+	}{
+		{"7d", now.Add(-7 * 24 * time.Hour)},
+		{"12h", now.Add(-12 * time.Hour)},
+		{"1.5h", now.Add(-90 * time.Minute)},
+		{"2w", now.Add(-14 * 24 * time.Hour)},
+		{"2024-01-31", time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local)},
+		{"2024-01-31 15:04", time.Date(2024, 1, 31, 15, 4, 0, 0, time.Local)},
+	}
+	for _, c := range cases {
+		got, err := ParseTime(c.in, now)
+		if err != nil {
+			t.Fatalf("ParseTime(%q) returned error: %v", c.in, err)
+		}
+		if !got.Equal(c.want) {
+			t.Fatalf("ParseTime(%q) = %v, want %v", c.in, got, c.want)
+		}
+	}
+	for _, in := range []string{"", "7", "d", "7x", "yesterday"} {
+		if _, err := ParseTime(in, now); err == nil {
+			t.Fatalf("expected error for %q", in)
+		}
+	}
+}
+
+// TestFindFilters runs Find with each filter against a small tree.
+func TestFindFilters(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	write := func(rel string, size int, age time.Duration) {
+		path := filepath.Join(td, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir for %s: %v", rel, err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+		mtime := time.Now().Add(-age)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("chtimes %s: %v", rel, err)
+		}
+	}
+	write("main.go", 100, 0)
+	write("README.MD", 2000, 48*time.Hour)
+	write("empty.txt", 0, 0)
+	write("src/deep/lib.go", 5000, 0)
+	if err := os.Mkdir(filepath.Join(td, "hollow"), 0o755); err != nil {
+		t.Fatalf("mkdir hollow: %v", err)
+	}
+	if err := os.Symlink("main.go", filepath.Join(td, "link")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		filter FileFilter
+		want   []string
+	}{
+		{"dirs", FileFilter{Types: TypeDir}, []string{"hollow", "src", "src/deep"}},
+		{"symlinks", FileFilter{Types: TypeSymlink}, []string{"link"}},
+		{"ext", FileFilter{Extensions: []string{"go", ".md"}}, []string{"README.MD", "main.go", "src/deep/lib.go"}},
+		{"min size", FileFilter{MinSize: 1000}, []string{"README.MD", "src/deep/lib.go"}},
+		{"max size", FileFilter{Types: TypeFile, MaxSize: 100}, []string{"empty.txt", "main.go"}},
+		{"older", FileFilter{Types: TypeFile, OlderThan: time.Now().Add(-24 * time.Hour)}, []string{"README.MD"}},
+		{"newer", FileFilter{Types: TypeFile, NewerThan: time.Now().Add(-24 * time.Hour)}, []string{"empty.txt", "main.go", "src/deep/lib.go"}},
+		{"empty", FileFilter{Empty: true}, []string{"empty.txt", "hollow"}},
+		{"max depth", FileFilter{Types: TypeFile | TypeDir, MaxDepth: 1}, []string{"README.MD", "empty.txt", "hollow", "main.go", "src"}},
+	}
+	for _, c := range cases {
+		results, err := Find(context.Background(), td, SearchOptions{Filter: c.filter, SortByPath: true})
+		if err != nil {
+			t.Fatalf("%s: Find returned error: %v", c.name, err)
+		}
+		var got []string
+		for _, path := range results {
+			rel, _ := filepath.Rel(td, path)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		sort.Strings(c.want)
+		if len(got) != len(c.want) {
+			t.Fatalf("%s: got %v, want %v", c.name, got, c.want)
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Fatalf("%s: got %v, want %v", c.name, got, c.want)
+			}
+		}
+	}
+}
+
+// TestSearchWithFilter checks that filters combine with a name query.
+func TestSearchWithFilter(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	for _, rel := range []string{"report.go", "report.md", "sub/report.go"} {
+		path := filepath.Join(td, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir for %s: %v", rel, err)
+		}
+		if err := os.WriteFile(path, []byte("report"), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+
+	results, err := SearchByName(context.Background(), "report", td, SearchOptions{Filter: FileFilter{Extensions: []string{"go"}, MaxDepth: 1}})
+	if err != nil {
+		t.Fatalf("SearchByName returned error: %v", err)
+	}
+	if len(results) != 1 || filepath.Base(results[0]) != "report.go" || filepath.Dir(results[0]) != td {
+		t.Fatalf("expected only the top-level report.go, got %v", results)
+	}
+
+	// Directories are skipped by content search even when the filter includes them
+	content, err := SearchByContent(context.Background(), "report", td, SearchOptions{Filter: FileFilter{Types: TypeFile | TypeDir}})
+	if err != nil {
+		t.Fatalf("SearchByContent returned error: %v", err)
+	}
+	if len(content) != 3 {
+		t.Fatalf("expected 3 files, got %v", content)
+	}
+}
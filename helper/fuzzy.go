@@ -0,0 +1,245 @@
+package helper
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Fuzzy scoring weights, modeled on fzf. Every matched character scores
+// scoreMatch plus a bonus for where it sits; gaps between matched characters
+// cost gapStart for the first skipped character and gapExtension for each one after.
+const (
+	scoreMatch        = 16
+	gapStart          = -3
+	gapExtension      = -1
+	bonusBoundary     = scoreMatch / 2
+	bonusSeparator    = bonusBoundary + 2
+	bonusNonWord      = scoreMatch / 2
+	bonusCamel        = bonusBoundary - 1
+	bonusConsecutive  = -(gapStart + gapExtension)
+	bonusFirstChar    = 2
+	bonusBaseName     = 2
+	fuzzyNoMatchScore = -1 << 30
+)
+
+// FuzzyMatch is a path ranked by how well it matches a fuzzy query.
+type FuzzyMatch struct {
+	// Path is the matched file.
+	Path string
+	// Target is the slash-separated path relative to the search directory that was scored.
+	Target string
+	// Score is higher for better matches.
+	Score int
+	// Spans are the byte ranges of Target holding the matched characters.
+	Spans [][]int
+}
+
+// fuzzyClass groups characters for the boundary bonuses.
+type fuzzyClass int
+
+const (
+	classSeparator fuzzyClass = iota
+	classNonWord
+	classLower
+	classUpper
+	classDigit
+)
+
+func classOf(r rune) fuzzyClass {
+	switch {
+	case r == '/':
+		return classSeparator
+	case unicode.IsLower(r):
+		return classLower
+	case unicode.IsUpper(r):
+		return classUpper
+	case unicode.IsDigit(r):
+		return classDigit
+	case unicode.IsLetter(r):
+		return classLower
+	}
+	return classNonWord
+}
+
+// positionBonus returns the bonus for matching a character of class cur right after one of class prev.
+func positionBonus(prev, cur fuzzyClass) int {
+	switch {
+	case cur == classNonWord || cur == classSeparator:
+		return bonusNonWord
+	case prev == classSeparator:
+		return bonusSeparator
+	case prev == classNonWord:
+		return bonusBoundary
+	case prev == classLower && cur == classUpper, prev != classDigit && cur == classDigit:
+		return bonusCamel
+	}
+	return 0
+}
+
+// FuzzyScore scores target against query, where the characters of query must
+// appear in target in order but not necessarily next to each other. Matching is
+// case-insensitive unless query contains an upper-case letter. Runs of
+// consecutive characters, characters at the start of a word or path segment,
+// and characters in the last path segment score higher. ok is false if target
+// doesn't contain the query's characters in order.
+func FuzzyScore(query, target string) (score int, spans [][]int, ok bool) {
+	caseSensitive := strings.IndexFunc(query, unicode.IsUpper) >= 0
+	fold := func(r rune) rune {
+		if caseSensitive {
+			return r
+		}
+		return unicode.ToLower(r)
+	}
+	pattern := []rune(query)
+	for i := range pattern {
+		pattern[i] = fold(pattern[i])
+	}
+	if len(pattern) == 0 {
+		return 0, nil, true
+	}
+
+	text := make([]rune, 0, len(target))
+	offsets := make([]int, 0, len(target)+1)
+	for i, r := range target {
+		text = append(text, fold(r))
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(target))
+
+	// Cheap check that every query character appears in order before scoring
+	next := 0
+	for _, r := range text {
+		if next < len(pattern) && r == pattern[next] {
+			next++
+		}
+	}
+	if next < len(pattern) {
+		return 0, nil, false
+	}
+
+	n, m := len(text), len(pattern)
+	baseStart := strings.LastIndex(target, "/") + 1
+	bonus := make([]int, n)
+	prev := classSeparator
+	j := 0
+	for i, r := range target {
+		cur := classOf(r)
+		bonus[j] = positionBonus(prev, cur)
+		if i >= baseStart {
+			bonus[j] += bonusBaseName
+		}
+		prev = cur
+		j++
+	}
+
+	// dp[i][j] is the best score for matching pattern[:i+1] with pattern[i]
+	// at text[j]; from[i][j] is where pattern[i-1] matched on that path.
+	dp := make([][]int, m)
+	from := make([][]int, m)
+	for i := range m {
+		dp[i] = make([]int, n)
+		from[i] = make([]int, n)
+		for j := range n {
+			dp[i][j] = fuzzyNoMatchScore
+		}
+	}
+	for j := range n {
+		if text[j] == pattern[0] {
+			dp[0][j] = scoreMatch + bonus[j]*bonusFirstChar
+		}
+	}
+	for i := 1; i < m; i++ {
+		// gapBest is the best dp[i-1][k] for k < j-1, less the gap penalty up to j
+		gapBest, gapFrom := fuzzyNoMatchScore, -1
+		for j := i; j < n; j++ {
+			if j >= 2 {
+				gapBest += gapExtension
+				if s := dp[i-1][j-2]; s > fuzzyNoMatchScore && s+gapStart > gapBest {
+					gapBest, gapFrom = s+gapStart, j-2
+				}
+			}
+			if text[j] != pattern[i] {
+				continue
+			}
+			if gapFrom >= 0 && gapBest > fuzzyNoMatchScore/2 {
+				dp[i][j] = gapBest + scoreMatch + bonus[j]
+				from[i][j] = gapFrom
+			}
+			if s := dp[i-1][j-1]; s > fuzzyNoMatchScore {
+				run := s + scoreMatch + max(bonus[j], bonusConsecutive)
+				if run >= dp[i][j] {
+					dp[i][j] = run
+					from[i][j] = j - 1
+				}
+			}
+		}
+	}
+
+	best, end := fuzzyNoMatchScore, -1
+	for j := range n {
+		if dp[m-1][j] > best {
+			best, end = dp[m-1][j], j
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+
+	positions := make([]int, m)
+	for i := m - 1; i >= 0; i-- {
+		positions[i] = end
+		end = from[i][end]
+	}
+	for _, p := range positions {
+		if len(spans) > 0 && spans[len(spans)-1][1] == offsets[p] {
+			spans[len(spans)-1][1] = offsets[p+1]
+			continue
+		}
+		spans = append(spans, []int{offsets[p], offsets[p+1]})
+	}
+	return best, spans, true
+}
+
+// FuzzySearch scores every file under dir against query using the path relative
+// to dir, and returns the limit best matches ranked by score. Ties go to the
+// shorter path. A limit of zero or less returns every match.
+// If some paths could not be read, the matches found elsewhere are returned along with a *WalkError.
+func FuzzySearch(ctx context.Context, query string, dir string, opts SearchOptions, limit int) ([]FuzzyMatch, error) {
+	var mu sync.Mutex
+	var matches []FuzzyMatch
+	err := walkFiles(ctx, dir, opts, func(path string, d fs.DirEntry) error {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.ToSlash(rel)
+		score, spans, ok := FuzzyScore(query, target)
+		if !ok {
+			return nil
+		}
+		mu.Lock()
+		matches = append(matches, FuzzyMatch{Path: path, Target: target, Score: score, Spans: spans})
+		mu.Unlock()
+		return nil
+	})
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if la, lb := utf8.RuneCountInString(a.Target), utf8.RuneCountInString(b.Target); la != lb {
+			return la < lb
+		}
+		return a.Target < b.Target
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, notFound(err)
+}
@@ -0,0 +1,101 @@
+package helper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestFuzzyScoreRanking checks that the bonuses rank paths the way a person would.
+func TestFuzzyScoreRanking(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		query, better, worse string
+	}{
+		// Matches at the start of a path segment beat matches inside a word
+		{"main", "cmd/main.go", "domain/x.go"},
+		// Consecutive characters beat scattered ones
+		{"abc", "abc.txt", "axxbxxc.txt"},
+		// Word boundaries and camel case beat arbitrary positions
+		{"fb", "foo_bar.go", "xfxbx.go"},
+		{"fb", "fooBar.go", "fxxxb.go"},
+		// The file name counts more than its directories
+		{"conf", "src/config.go", "conf/other.go"},
+	}
+	for _, c := range cases {
+		better, _, ok := FuzzyScore(c.query, c.better)
+		if !ok {
+			t.Fatalf("%q should match %q", c.query, c.better)
+		}
+		worse, _, ok := FuzzyScore(c.query, c.worse)
+		if !ok {
+			t.Fatalf("%q should match %q", c.query, c.worse)
+		}
+		if better <= worse {
+			t.Fatalf("query %q: %q scored %d, expected more than %q with %d", c.query, c.better, better, c.worse, worse)
+		}
+	}
+}
+
+// TestFuzzyScoreMatching checks smart case, ordering and the returned spans.
+func TestFuzzyScoreMatching(t *testing.T) {
+	t.Parallel()
+
+	if _, _, ok := FuzzyScore("read", "docs/README.md"); !ok {
+		t.Fatalf("lower-case queries should match regardless of case")
+	}
+	if _, _, ok := FuzzyScore("Read", "docs/readme.md"); ok {
+		t.Fatalf("queries with upper case should match case-sensitively")
+	}
+	if _, _, ok := FuzzyScore("ba", "abc"); ok {
+		t.Fatalf("characters must appear in order")
+	}
+	_, spans, ok := FuzzyScore("mgo", "cmd/main.go")
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	if want := [][]int{{4, 5}, {9, 11}}; !reflect.DeepEqual(spans, want) {
+		t.Fatalf("spans = %v, want %v", spans, want)
+	}
+	_, spans, _ = FuzzyScore("é", "café.txt")
+	if want := [][]int{{3, 5}}; !reflect.DeepEqual(spans, want) {
+		t.Fatalf("spans for multi-byte runes = %v, want %v", spans, want)
+	}
+}
+
+// TestFuzzySearch checks ranking, the limit and ignore rules during a walk.
+func TestFuzzySearch(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	for _, rel := range []string{"cmd/search.go", "helper/search_test.go", "docs/semantics.md", "build/search.go", ".gitignore"} {
+		path := filepath.Join(td, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir for %s: %v", rel, err)
+		}
+		content := ""
+		if rel == ".gitignore" {
+			content = "build/\n"
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+
+	matches, err := FuzzySearch(context.Background(), "srch", td, SearchOptions{RespectIgnore: true}, 2)
+	if err != nil {
+		t.Fatalf("FuzzySearch returned error: %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("expected the limit of 2 matches, got %v", matches)
+	}
+	if matches[0].Target != "cmd/search.go" || matches[1].Target != "helper/search_test.go" {
+		t.Fatalf("unexpected ranking: %+v", matches)
+	}
+	if matches[0].Score < matches[1].Score {
+		t.Fatalf("matches should be ordered by score")
+	}
+}
@@ -0,0 +1,172 @@
+package helper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// GitState is the working-tree state of a path in a git repository.
+// Larger values take precedence when rolling up a directory's children.
+type GitState int
+
+const (
+	GitClean GitState = iota
+	GitIgnored
+	GitUntracked
+	GitStaged
+	GitModified
+	GitConflicted
+)
+
+// String returns the label shown in the git column.
+func (s GitState) String() string {
+	switch s {
+	case GitIgnored:
+		return "ignored"
+	case GitUntracked:
+		return "untracked"
+	case GitStaged:
+		return "staged"
+	case GitModified:
+		return "modified"
+	case GitConflicted:
+		return "conflicted"
+	}
+	return "-"
+}
+
+// GitStatus holds the state of every non-clean path in a repository.
+type GitStatus struct {
+	root   string
+	states map[string]GitState
+	// dirs holds the state each directory rolls up from its non-ignored children.
+	dirs map[string]GitState
+}
+
+// LoadGitStatus reads the status of the repository containing dir by running
+// `git status --porcelain=v2`.
+func LoadGitStatus(dir string) (*GitStatus, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, errors.New("git is not installed")
+	}
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil, fmt.Errorf("not a git repository: %s", dir)
+	}
+	root := strings.TrimSpace(string(out))
+
+	out, err = exec.Command("git", "-C", root, "status", "--porcelain=v2", "-z", "--untracked-files=all", "--ignored=matching").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git status failed: %v", err)
+	}
+	states := parsePorcelainV2(out)
+	return &GitStatus{root: root, states: states, dirs: rollUpDirs(states)}, nil
+}
+
+// rollUpDirs returns, for every directory holding a non-clean path, the
+// highest state below it. Ignored children don't make a tracked directory
+// look ignored, so they are left out.
+func rollUpDirs(states map[string]GitState) map[string]GitState {
+	dirs := map[string]GitState{}
+	for p, s := range states {
+		if s == GitIgnored {
+			continue
+		}
+		for dir := path.Dir(p); ; dir = path.Dir(dir) {
+			if s > dirs[dir] {
+				dirs[dir] = s
+			}
+			if dir == "." || dir == "/" {
+				break
+			}
+		}
+	}
+	return dirs
+}
+
+// parsePorcelainV2 parses NUL-separated `git status --porcelain=v2 -z` output
+// into states keyed by slash-separated paths relative to the repository root.
+func parsePorcelainV2(data []byte) map[string]GitState {
+	states := map[string]GitState{}
+	records := bytes.Split(data, []byte{0})
+	for i := 0; i < len(records); i++ {
+		record := string(records[i])
+		if len(record) < 2 {
+			continue
+		}
+		switch record[0] {
+		case '1', '2':
+			// "1 XY sub mH mI mW hH hI path", renames have an extra score field
+			// and are followed by a record holding the original path.
+			fieldCount := 9
+			if record[0] == '2' {
+				fieldCount = 10
+				i++
+			}
+			fields := strings.SplitN(record, " ", fieldCount)
+			if len(fields) == fieldCount {
+				states[fields[fieldCount-1]] = xyState(fields[1])
+			}
+		case 'u':
+			fields := strings.SplitN(record, " ", 11)
+			if len(fields) == 11 {
+				states[fields[10]] = GitConflicted
+			}
+		case '?':
+			states[record[2:]] = GitUntracked
+		case '!':
+			states[strings.TrimSuffix(record[2:], "/")] = GitIgnored
+		}
+	}
+	return states
+}
+
+// xyState maps the XY field of a changed entry to a state. X is the staged
+// change and Y the unstaged one; unstaged changes take precedence.
+func xyState(xy string) GitState {
+	if len(xy) != 2 {
+		return GitClean
+	}
+	if xy[1] != '.' {
+		return GitModified
+	}
+	if xy[0] != '.' {
+		return GitStaged
+	}
+	return GitClean
+}
+
+// State returns the state of path. Directories are rolled up from their
+// children, and anything inside an ignored directory is ignored.
+func (g *GitStatus) State(path string, isDir bool) GitState {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return GitClean
+	}
+	// Resolve symlinks in the parent so paths like /tmp vs /private/tmp line up
+	// with git's root, without following path itself if it is a symlink
+	if resolved, err := filepath.EvalSymlinks(filepath.Dir(absPath)); err == nil {
+		absPath = filepath.Join(resolved, filepath.Base(absPath))
+	}
+	rel, err := filepath.Rel(g.root, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return GitClean
+	}
+	rel = filepath.ToSlash(rel)
+
+	state := g.states[rel]
+	for dir := rel; dir != "." && dir != "/"; dir = filepath.ToSlash(filepath.Dir(dir)) {
+		if g.states[dir] == GitIgnored {
+			return GitIgnored
+		}
+	}
+	if isDir && g.dirs[rel] > state {
+		state = g.dirs[rel]
+	}
+	return state
+}
@@ -0,0 +1,102 @@
+package helper
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestParsePorcelainV2 checks each record type of the porcelain v2 format.
+func TestParsePorcelainV2(t *testing.T) {
+	t.Parallel()
+
+	data := "1 .M N... 100644 100644 100644 abc abc mod.txt\x00" +
+		"1 A. N... 000000 100644 100644 000 abc added.txt\x00" +
+		"2 R. N... 100644 100644 100644 abc abc R100 new name.txt\x00old.txt\x00" +
+		"u UU N... 100644 100644 100644 100644 a b c both.txt\x00" +
+		"? dir/untracked.txt\x00" +
+		"! build/\x00"
+
+	states := parsePorcelainV2([]byte(data))
+	want := map[string]GitState{
+		"mod.txt":           GitModified,
+		"added.txt":         GitStaged,
+		"new name.txt":      GitStaged,
+		"both.txt":          GitConflicted,
+		"dir/untracked.txt": GitUntracked,
+		"build":             GitIgnored,
+	}
+	if len(states) != len(want) {
+		t.Fatalf("expected %d states, got %d: %v", len(want), len(states), states)
+	}
+	for path, state := range want {
+		if states[path] != state {
+			t.Fatalf("state for %q = %v, want %v", path, states[path], state)
+		}
+	}
+}
+
+// TestGitStatusState runs git in a temporary repository and checks file and
+// rolled-up directory states.
+func TestGitStatusState(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	td := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", td, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+	}
+	write := func(rel, content string) {
+		path := filepath.Join(td, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir for %s: %v", rel, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+
+	run("init", "-q")
+	write("tracked.txt", "one")
+	write("src/code.go", "package src")
+	run("add", ".")
+	run("commit", "-q", "-m", "initial")
+	write("src/code.go", "package src // changed")
+	write("new.txt", "new")
+	write(".gitignore", "*.log\n")
+	write("debug.log", "log")
+	write("..notes", "starts with dots but is inside the repository")
+	if err := os.Symlink("tracked.txt", filepath.Join(td, "link.txt")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	status, err := LoadGitStatus(td)
+	if err != nil {
+		t.Fatalf("LoadGitStatus returned error: %v", err)
+	}
+	cases := []struct {
+		rel   string
+		isDir bool
+		want  GitState
+	}{
+		{"tracked.txt", false, GitClean},
+		{"src/code.go", false, GitModified},
+		{"src", true, GitModified},
+		{"new.txt", false, GitUntracked},
+		{"debug.log", false, GitIgnored},
+		{"..notes", false, GitUntracked},
+		// A symlink has its own state, not that of its target
+		{"link.txt", false, GitUntracked},
+		{".", true, GitModified},
+	}
+	for _, c := range cases {
+		if got := status.State(filepath.Join(td, c.rel), c.isDir); got != c.want {
+			t.Fatalf("State(%q) = %v, want %v", c.rel, got, c.want)
+		}
+	}
+}
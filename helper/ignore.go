@@ -0,0 +1,223 @@
+package helper
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ignoreFiles are the per-directory ignore files, in increasing order of precedence.
+var ignoreFiles = []string{".gitignore", ".ignore"}
+
+// ignoreRule is a single compiled pattern from an ignore file.
+type ignoreRule struct {
+	base    string
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// IgnoreMatcher reports whether paths are excluded by .gitignore, .ignore and
+// .git/info/exclude files. Ignore files are read lazily, once per directory,
+// so the matcher can be shared across a walk. It is safe for concurrent use.
+type IgnoreMatcher struct {
+	top   string
+	mu    sync.Mutex
+	rules map[string][]ignoreRule
+}
+
+// NewIgnoreMatcher creates a matcher for walks rooted at root. If root is
+// inside a git repository, ignore files between the repository root and root
+// also apply, along with the repository's .git/info/exclude.
+func NewIgnoreMatcher(root string) (*IgnoreMatcher, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	m := &IgnoreMatcher{top: absRoot, rules: map[string][]ignoreRule{}}
+	if repo := findRepoRoot(absRoot); repo != "" {
+		m.top = repo
+		exclude := filepath.Join(repo, ".git", "info", "exclude")
+		m.rules[repo] = append(readIgnoreFile(exclude, repo), m.loadDir(repo)...)
+	}
+	return m, nil
+}
+
+// findRepoRoot returns the closest directory at or above dir that contains .git.
+func findRepoRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Ignored reports whether path is excluded by the ignore files of its ancestors.
+// The last matching pattern wins, so negated patterns can re-include a path.
+func (m *IgnoreMatcher) Ignored(path string, isDir bool) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	if isDir && filepath.Base(absPath) == ".git" {
+		return true
+	}
+	rel, err := filepath.Rel(m.top, absPath)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+
+	ignored := false
+	dir := m.top
+	parts := strings.Split(rel, string(filepath.Separator))
+	for i := range parts {
+		for _, rule := range m.rulesFor(dir) {
+			if rule.dirOnly && !isDir {
+				continue
+			}
+			relToBase, err := filepath.Rel(rule.base, absPath)
+			if err != nil {
+				continue
+			}
+			if rule.re.MatchString(filepath.ToSlash(relToBase)) {
+				ignored = !rule.negate
+			}
+		}
+		dir = filepath.Join(dir, parts[i])
+	}
+	return ignored
+}
+
+// rulesFor returns the rules defined in dir, reading its ignore files on first use.
+func (m *IgnoreMatcher) rulesFor(dir string) []ignoreRule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rules, ok := m.rules[dir]
+	if !ok {
+		rules = m.loadDir(dir)
+		m.rules[dir] = rules
+	}
+	return rules
+}
+
+// loadDir reads the ignore files in dir.
+func (m *IgnoreMatcher) loadDir(dir string) []ignoreRule {
+	var rules []ignoreRule
+	for _, name := range ignoreFiles {
+		rules = append(rules, readIgnoreFile(filepath.Join(dir, name), dir)...)
+	}
+	return rules
+}
+
+// readIgnoreFile parses an ignore file whose patterns are relative to base.
+// A missing or unreadable file yields no rules.
+func readIgnoreFile(path, base string) []ignoreRule {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreLine(scanner.Text(), base); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// parseIgnoreLine compiles one line of an ignore file using gitignore semantics.
+func parseIgnoreLine(line, base string) (ignoreRule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	if !strings.HasSuffix(line, `\ `) {
+		line = strings.TrimRight(line, " ")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	// Patterns without a slash match at any depth; others are anchored to base.
+	if strings.Contains(line, "/") {
+		line = strings.TrimPrefix(line, "/")
+	} else {
+		line = "**/" + line
+	}
+	re, err := regexp.Compile("^" + globToRegexp(line) + "$")
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.re = re
+	return rule, true
+}
+
+// globToRegexp converts a slash-separated glob into a regular expression.
+// "*" and "?" never match "/", while "**" matches any number of directories.
+func globToRegexp(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				atStart := i == 0 || pattern[i-1] == '/'
+				i++
+				if atStart && i+1 < len(pattern) && pattern[i+1] == '/' {
+					// "**/" matches zero or more leading directories
+					b.WriteString("(?:.*/)?")
+					i++
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,130 @@
+package helper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGlobToRegexp checks the translation of single and double star patterns.
+func TestGlobToRegexp(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.log", "app.log", true},
+		{"*.log", "logs/app.log", false},
+		{"**/*.log", "logs/deep/app.log", true},
+		{"**/*.log", "app.log", true},
+		{"build/**", "build/out/bin", true},
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"file?.txt", "file1.txt", true},
+		{"file[!0-9].txt", "file1.txt", false},
+		{"file[!0-9].txt", "filex.txt", true},
+	}
+	for _, c := range cases {
+		rule, ok := parseIgnoreLine("/"+c.pattern, "")
+		if !ok {
+			t.Fatalf("failed to parse pattern %q", c.pattern)
+		}
+		if got := rule.re.MatchString(c.path); got != c.want {
+			t.Fatalf("pattern %q against %q = %v, want %v", c.pattern, c.path, got, c.want)
+		}
+	}
+}
+
+// TestIgnoreMatcherHierarchy verifies nested ignore files, negation, directory-only
+// patterns and .git/info/exclude.
+func TestIgnoreMatcherHierarchy(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(td, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir for %s: %v", rel, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+	write(".git/info/exclude", "secret.txt\n")
+	write(".gitignore", "# comment\n*.log\n!keep.log\nbuild/\n")
+	write("sub/.ignore", "/local.txt\n")
+
+	m, err := NewIgnoreMatcher(filepath.Join(td, "sub"))
+	if err != nil {
+		t.Fatalf("NewIgnoreMatcher returned error: %v", err)
+	}
+	cases := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"sub/app.log", false, true},
+		{"sub/keep.log", false, false},
+		{"sub/build", true, true},
+		{"sub/build", false, false},
+		{"sub/secret.txt", false, true},
+		{"sub/local.txt", false, true},
+		{"sub/deeper/local.txt", false, false},
+		{"sub/main.go", false, false},
+		{".git", true, true},
+	}
+	for _, c := range cases {
+		if got := m.Ignored(filepath.Join(td, c.rel), c.isDir); got != c.want {
+			t.Fatalf("Ignored(%q, dir=%v) = %v, want %v", c.rel, c.isDir, got, c.want)
+		}
+	}
+}
+
+// TestSearchAndTreeRespectIgnore ensures ignored subtrees are pruned from walks.
+func TestSearchAndTreeRespectIgnore(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	if err := os.WriteFile(filepath.Join(td, ".gitignore"), []byte("out/\n"), 0o644); err != nil {
+		t.Fatalf("write .gitignore: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(td, "out"), 0o755); err != nil {
+		t.Fatalf("mkdir out: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(td, "out", "target.txt"), []byte("needle"), 0o644); err != nil {
+		t.Fatalf("write target: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(td, "target.md"), []byte("needle"), 0o644); err != nil {
+		t.Fatalf("write target: %v", err)
+	}
+
+	results, err := SearchByName(context.Background(), "target", td, SearchOptions{RespectIgnore: true})
+	if err != nil {
+		t.Fatalf("SearchByName returned error: %v", err)
+	}
+	if len(results) != 1 || !strings.HasSuffix(results[0], "target.md") {
+		t.Fatalf("expected only target.md, got %v", results)
+	}
+
+	results, err = SearchByContent(context.Background(), "needle", td, SearchOptions{})
+	if err != nil {
+		t.Fatalf("SearchByContent returned error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected both files without ignore rules, got %v", results)
+	}
+
+	tree, err := GetDirectoryTree(context.Background(), td, false, true)
+	if err != nil {
+		t.Fatalf("GetDirectoryTree returned error: %v", err)
+	}
+	for _, e := range tree {
+		if strings.Contains(e.Path, "target.txt") || e.DirEntry.Name() == "out" {
+			t.Fatalf("ignored entry %q should not appear in tree", e.Path)
+		}
+	}
+}
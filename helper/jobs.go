@@ -0,0 +1,207 @@
+package helper
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AutoJobs is the worker count that makes RunConcurrent pick the count from
+// the storage the paths are on and tune it while it runs.
+const AutoJobs = 0
+
+// ParseJobs parses the value of the --jobs flag: a positive number of
+// workers, or auto for AutoJobs.
+func ParseJobs(s string) (int, error) {
+	if s == "" || strings.EqualFold(s, "auto") {
+		return AutoJobs, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return AutoJobs, fmt.Errorf("invalid jobs %q (expected a positive number or auto)", s)
+	}
+	return n, nil
+}
+
+// storageKind is the kind of device a path is stored on.
+type storageKind int
+
+const (
+	storageUnknown storageKind = iota
+	storageRotational
+	storageSolid
+	storageNetwork
+)
+
+// Limits of the automatic worker count.
+const (
+	minAutoJobs = 1
+	maxAutoJobs = 64
+	// autoSampleDirs bounds how many directories are checked for their storage.
+	autoSampleDirs = 16
+)
+
+// jobsFor returns the worker count to start with on a kind of storage.
+// Spinning disks slow down when reads compete for the head, while SSDs and
+// network filesystems, whose latency hides behind parallel requests, keep up
+// with more.
+func jobsFor(kind storageKind) int {
+	switch kind {
+	case storageRotational:
+		return 1
+	case storageSolid:
+		return max(4, runtime.NumCPU())
+	case storageNetwork:
+		return 16
+	}
+	return 4
+}
+
+// storageJobs returns the worker count to start with for paths: the lowest
+// count of the storage they are on, as the slowest device bounds the batch.
+func storageJobs(paths []string) int {
+	jobs := 0
+	seen := map[string]bool{}
+	for _, path := range paths {
+		dir := filepath.Dir(filepath.Clean(path))
+		if seen[dir] {
+			continue
+		}
+		if len(seen) == autoSampleDirs {
+			break
+		}
+		seen[dir] = true
+		if n := jobsFor(storageOf(path)); jobs == 0 || n < jobs {
+			jobs = n
+		}
+	}
+	if jobs == 0 {
+		return jobsFor(storageUnknown)
+	}
+	return jobs
+}
+
+// jobTuner adjusts a worker count by hill climbing on throughput: it keeps
+// stepping the count the same way while throughput improves, turns around when
+// it drops, and holds while it stays within tolerance.
+type jobTuner struct {
+	jobs      int
+	step      int
+	lastRate  float64
+	tolerance float64
+}
+
+// newJobTuner returns a tuner starting at jobs workers and trying more first.
+func newJobTuner(jobs int) *jobTuner {
+	return &jobTuner{jobs: jobs, step: 1, tolerance: 0.05}
+}
+
+// next takes the throughput measured with the current count and returns the
+// count to use next. A window without progress, such as one spent waiting on
+// a single large file, says nothing about the count, so it is kept.
+func (t *jobTuner) next(rate float64) int {
+	switch {
+	case rate == 0:
+		return t.jobs
+	case t.lastRate == 0:
+		// The first measurement has nothing to compare to, so try a step
+	case rate > t.lastRate*(1+t.tolerance):
+	case rate < t.lastRate*(1-t.tolerance):
+		t.step = -t.step
+	default:
+		t.lastRate = rate
+		return t.jobs
+	}
+	t.lastRate = rate
+	t.jobs = min(max(t.jobs+t.step, minAutoJobs), maxAutoJobs)
+	return t.jobs
+}
+
+// workerLimit caps how many workers of a pool run a task at once. The cap can
+// change while the pool runs.
+type workerLimit struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  int
+	active int
+}
+
+func newWorkerLimit(limit int) *workerLimit {
+	l := &workerLimit{limit: limit}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// acquire waits until a worker may run.
+func (l *workerLimit) acquire() {
+	l.mu.Lock()
+	for l.active >= l.limit {
+		l.cond.Wait()
+	}
+	l.active++
+	l.mu.Unlock()
+}
+
+// release lets another worker run.
+func (l *workerLimit) release() {
+	l.mu.Lock()
+	l.active--
+	l.mu.Unlock()
+	l.cond.Broadcast()
+}
+
+// set changes the cap. Workers running over a lowered cap finish their task first.
+func (l *workerLimit) set(limit int) {
+	l.mu.Lock()
+	l.limit = limit
+	l.mu.Unlock()
+	l.cond.Broadcast()
+}
+
+// tuneInterval is how often an automatic pool measures its throughput.
+const tuneInterval = 500 * time.Millisecond
+
+// taskOverheadBytes is the work a finished task counts for on top of the
+// bytes it wrote, so batches of small files or deletions still have a throughput.
+const taskOverheadBytes = 64 << 10
+
+// bytesWritten counts the bytes written by copies, as they are written, so the
+// throughput of a batch includes files still being copied.
+var bytesWritten atomic.Int64
+
+// countingWriter is a writer that adds the bytes written to n.
+type countingWriter struct {
+	w io.Writer
+	n *atomic.Int64
+}
+
+func (c countingWriter) Write(p []byte) (int, error) {
+	written, err := c.w.Write(p)
+	c.n.Add(int64(written))
+	return written, err
+}
+
+// tuneWorkers measures the bytes done by a pool every interval and sets its
+// limit from tuner, until done is closed.
+func tuneWorkers(limit *workerLimit, tuner *jobTuner, interval time.Duration, progress func() int64, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last, lastTime := progress(), time.Now()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			current := progress()
+			rate := float64(current-last) / now.Sub(lastTime).Seconds()
+			last, lastTime = current, now
+			limit.set(tuner.next(rate))
+		}
+	}
+}
@@ -0,0 +1,71 @@
+//go:build linux
+
+package helper
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// sysBlockDir holds the block devices by major:minor number.
+const sysBlockDir = "/sys/dev/block"
+
+// networkFilesystems are the statfs magic numbers of network filesystems.
+var networkFilesystems = map[uint32]bool{
+	0x6969:     true, // NFS
+	0x517b:     true, // SMB
+	0xff534d42: true, // CIFS
+	0xfe534d42: true, // SMB2
+	0x01021997: true, // 9P
+	0x00c36400: true, // Ceph
+	0x5346414f: true, // AFS
+}
+
+// fuseMagic is the statfs magic number of FUSE filesystems, which may be
+// remote like sshfs or local like ntfs-3g, so they count as unknown storage.
+const fuseMagic = 0x65735546
+
+// storageOf returns the kind of storage path is on, from the filesystem type
+// and the rotational flag of its block device in sysfs.
+func storageOf(path string) storageKind {
+	var fsStat syscall.Statfs_t
+	if err := syscall.Statfs(path, &fsStat); err == nil {
+		switch {
+		case uint32(fsStat.Type) == fuseMagic:
+			return storageUnknown
+		case networkFilesystems[uint32(fsStat.Type)]:
+			return storageNetwork
+		}
+	}
+	var stat syscall.Stat_t
+	if err := syscall.Stat(path, &stat); err != nil {
+		return storageUnknown
+	}
+	dev := uint64(stat.Dev)
+	major := uint32((dev>>8)&0xfff | (dev>>32)&^0xfff)
+	minor := uint32(dev&0xff | (dev>>12)&^0xff)
+	return blockStorage(sysBlockDir, major, minor)
+}
+
+// blockStorage reads the rotational flag of a block device below root. A
+// partition has no queue of its own, so its parent disk's is used.
+func blockStorage(root string, major, minor uint32) storageKind {
+	device, err := filepath.EvalSymlinks(filepath.Join(root, fmt.Sprintf("%d:%d", major, minor)))
+	if err != nil {
+		return storageUnknown
+	}
+	for _, dir := range []string{device, filepath.Dir(device)} {
+		data, err := os.ReadFile(filepath.Join(dir, "queue", "rotational"))
+		if err != nil {
+			continue
+		}
+		if strings.TrimSpace(string(data)) == "1" {
+			return storageRotational
+		}
+		return storageSolid
+	}
+	return storageUnknown
+}
@@ -0,0 +1,52 @@
+//go:build linux
+
+package helper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestBlockStorage reads the rotational flag from a fake sysfs, including a
+// partition that takes it from its parent disk.
+func TestBlockStorage(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	devices := filepath.Join(root, "devices")
+	write := func(dir, rotational string) {
+		if err := os.MkdirAll(filepath.Join(dir, "queue"), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "queue", "rotational"), []byte(rotational+"\n"), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	write(filepath.Join(devices, "sda"), "1")
+	write(filepath.Join(devices, "nvme0n1"), "0")
+	if err := os.MkdirAll(filepath.Join(devices, "sda", "sda1"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	links := map[string]string{"8:0": "sda", "8:1": "sda/sda1", "259:0": "nvme0n1"}
+	for name, target := range links {
+		if err := os.Symlink(filepath.Join(devices, target), filepath.Join(root, name)); err != nil {
+			t.Fatalf("symlink: %v", err)
+		}
+	}
+
+	cases := []struct {
+		major, minor uint32
+		want         storageKind
+	}{
+		{8, 0, storageRotational},
+		{8, 1, storageRotational},
+		{259, 0, storageSolid},
+		{0, 42, storageUnknown},
+	}
+	for _, c := range cases {
+		if got := blockStorage(root, c.major, c.minor); got != c.want {
+			t.Fatalf("blockStorage(%d:%d) = %d, want %d", c.major, c.minor, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package helper
+
+// storageOf can't tell storage apart on this platform.
+func storageOf(path string) storageKind {
+	return storageUnknown
+}
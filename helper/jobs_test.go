@@ -0,0 +1,159 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestParseJobs checks the values accepted by --jobs.
+func TestParseJobs(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]int{"": AutoJobs, "auto": AutoJobs, "AUTO": AutoJobs, "1": 1, "12": 12}
+	for input, want := range cases {
+		got, err := ParseJobs(input)
+		if err != nil || got != want {
+			t.Fatalf("ParseJobs(%q) = %d, %v, want %d", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"0", "-2", "many", "1.5"} {
+		if _, err := ParseJobs(input); err == nil {
+			t.Fatalf("expected ParseJobs(%q) to fail", input)
+		}
+	}
+}
+
+// TestJobsFor checks that slower storage starts with fewer workers.
+func TestJobsFor(t *testing.T) {
+	t.Parallel()
+
+	rotational, unknown, solid, network := jobsFor(storageRotational), jobsFor(storageUnknown), jobsFor(storageSolid), jobsFor(storageNetwork)
+	if rotational != 1 || rotational >= unknown || unknown > solid || network > maxAutoJobs {
+		t.Fatalf("unexpected starting counts: rotational %d, unknown %d, solid %d, network %d", rotational, unknown, solid, network)
+	}
+	if got := storageJobs(nil); got != unknown {
+		t.Fatalf("expected %d workers without paths, got %d", unknown, got)
+	}
+}
+
+// TestJobTuner checks that the tuner climbs while throughput improves, turns
+// around when it drops and holds while it stays flat.
+func TestJobTuner(t *testing.T) {
+	t.Parallel()
+
+	tuner := newJobTuner(4)
+	steps := []struct {
+		rate float64
+		want int
+	}{
+		{100, 5}, // first measurement: try one more
+		{150, 6}, // better: keep climbing
+		{120, 5}, // worse: turn around
+		{121, 5}, // flat: hold
+		{200, 4}, // better: keep going down
+		{100, 5}, // worse: turn around again
+		{0, 5},   // no progress: keep the count
+		{0, 5},
+		{99, 5}, // flat against the last real measurement: hold
+	}
+	for i, step := range steps {
+		if got := tuner.next(step.rate); got != step.want {
+			t.Fatalf("step %d: got %d workers, want %d", i, got, step.want)
+		}
+	}
+
+	low := newJobTuner(minAutoJobs)
+	low.step = -1
+	low.next(100)
+	if got := low.next(200); got != minAutoJobs {
+		t.Fatalf("expected the count to stay at %d, got %d", minAutoJobs, got)
+	}
+}
+
+// TestCountingWriter checks that bytes are counted as they are written.
+func TestCountingWriter(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	var n atomic.Int64
+	w := countingWriter{w: &buf, n: &n}
+	for _, chunk := range []string{"hello ", "world"} {
+		if _, err := io.WriteString(w, chunk); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if n.Load() != int64(buf.Len()) {
+			t.Fatalf("counted %d bytes, wrote %d", n.Load(), buf.Len())
+		}
+	}
+}
+
+// TestTuneWorkers checks that a running pool's limit follows the tuner.
+func TestTuneWorkers(t *testing.T) {
+	t.Parallel()
+
+	limit := newWorkerLimit(2)
+	var progress atomic.Int64
+	done := make(chan struct{})
+	defer close(done)
+	go tuneWorkers(limit, newJobTuner(2), 5*time.Millisecond, func() int64 {
+		// Throughput keeps growing, so the tuner keeps adding workers
+		return progress.Add(progress.Load() + 1)
+	}, done)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		limit.mu.Lock()
+		current := limit.limit
+		limit.mu.Unlock()
+		if current > 3 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the limit to grow, still %d", current)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestRunConcurrentAuto verifies that an automatic worker count processes every path.
+func TestRunConcurrentAuto(t *testing.T) {
+	t.Parallel()
+
+	tdir := t.TempDir()
+	var matches []string
+	for i := range 20 {
+		path := filepath.Join(tdir, fmt.Sprintf("file%d", i))
+		if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+		matches = append(matches, path)
+	}
+
+	var processed, running, peak atomic.Int32
+	task := func(info os.FileInfo, path string) error {
+		n := running.Add(1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		processed.Add(1)
+		return nil
+	}
+	if err := RunConcurrent(context.Background(), task, AutoJobs, matches); err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if processed.Load() != int32(len(matches)) {
+		t.Fatalf("expected %d processed, got %d", len(matches), processed.Load())
+	}
+	// Tuning only adds a worker every half second, so a quick batch stays at the starting count
+	if int(peak.Load()) > storageJobs(matches) {
+		t.Fatalf("expected at most %d tasks at once before tuning, got %d", storageJobs(matches), peak.Load())
+	}
+}
@@ -1,15 +1,18 @@
 package helper
 
 import (
-	"fmt"
+	"context"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 )
 
 type Entry struct {
 	Path     string
+	FullPath string
 	DirEntry fs.DirEntry
 }
 
@@ -22,18 +25,6 @@ func GetDirectoryFromArgs(args []string, numArgs int) (string, error) {
 	}
 }
 
-// FormatSize formats the size in bytes to a human-readable format.
-func FormatSize(size int64) string {
-	if size >= 1024*1024*1024 {
-		return fmt.Sprintf("%.2f GB", float64(size)/float64(1024*1024*1024))
-	} else if size >= 1024*1024 {
-		return fmt.Sprintf("%.2f MB", float64(size)/float64(1024*1024))
-	} else if size >= 1024 {
-		return fmt.Sprintf("%.2f KB", float64(size)/float64(1024))
-	}
-	return fmt.Sprintf("%d Bytes", size)
-}
-
 // Calculate total size of a directory (recursively)
 func GetDirSize(path string) (int64, error) {
 	var totalSize int64
@@ -54,16 +45,6 @@ func GetDirSize(path string) (int64, error) {
 	return totalSize, err
 }
 
-// isParentHidden checks if any parent directory is hidden, relative to basePath
-func isParentHidden(currentPath, basePath string) bool {
-	for path := currentPath; path != basePath; path = filepath.Dir(path) {
-		if strings.HasPrefix(filepath.Base(path), ".") {
-			return true
-		}
-	}
-	return false
-}
-
 // GetFileListing returns a list of files in a directory.
 func GetFileListing(path string, includeHidden bool) ([]Entry, error) {
 	files, err := os.ReadDir(path)
@@ -76,38 +57,79 @@ func GetFileListing(path string, includeHidden bool) ([]Entry, error) {
 			continue
 		}
 		fullPath := file.Name()
-		entries = append(entries, Entry{Path: fullPath, DirEntry: file})
+		entries = append(entries, Entry{Path: fullPath, FullPath: filepath.Join(path, file.Name()), DirEntry: file})
 	}
 	return entries, nil
 }
 
-// GetDirectoryTree returns a tree structure of a directory.
-func GetDirectoryTree(path string, includeHidden bool) ([]Entry, error) {
+// GetDirectoryTree returns a tree structure of a directory. If respectIgnore is true,
+// entries excluded by .gitignore, .ignore or .git/info/exclude are skipped.
+// The directory is read concurrently, and the entries are then put in the
+// order filepath.WalkDir visits them. Entries that can't be read are skipped
+// and reported in a *WalkError returned alongside the rest of the tree. If ctx
+// is cancelled the walk stops and ctx's error is returned.
+func GetDirectoryTree(ctx context.Context, path string, includeHidden bool, respectIgnore bool) ([]Entry, error) {
+	var ignore *IgnoreMatcher
+	if respectIgnore {
+		var err error
+		ignore, err = NewIgnoreMatcher(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var mu sync.Mutex
 	var entries []Entry
-	err := filepath.WalkDir(path, func(currentPath string, d fs.DirEntry, err error) error {
+	var failures walkErrors
+	err := Walk(path, 0, func(currentPath string, d fs.DirEntry, err error) error {
 		if err != nil {
+			if currentPath == path {
+				return err
+			}
+			failures.add(currentPath, err)
 			return nil
 		}
-		// Check if the current entry is a file/directory
-		isHidden := !includeHidden && strings.HasPrefix(d.Name(), ".")
-
-		// If it's hidden, check upper directories
-		if !includeHidden {
-			if isHidden || isParentHidden(currentPath, path) {
-				return nil // Skip the entry if it's hidden or in hidden directory
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// Hidden entries are left out along with everything inside them
+		if !includeHidden && strings.HasPrefix(d.Name(), ".") {
+			if currentPath == path {
+				return nil
+			}
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// Prune ignored directories so their contents are never read
+		if ignore != nil && currentPath != path && ignore.Ignored(currentPath, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
 			}
+			return nil
 		}
-		if path == currentPath {
-			entries = append(entries, Entry{Path: path, DirEntry: d})
-		} else {
+		entry := Entry{Path: path, FullPath: path, DirEntry: d}
+		if path != currentPath {
 			relPath := strings.Replace(currentPath, path, "", 1)
 			nestCount := strings.Count(relPath, string(filepath.Separator))
 			pathStr := strings.Repeat("│   ", nestCount)
-			fullPath := filepath.Join(pathStr, d.Name())
-			entries = append(entries, Entry{Path: fullPath, DirEntry: d})
+			entry = Entry{Path: filepath.Join(pathStr, d.Name()), FullPath: currentPath, DirEntry: d}
 		}
+		mu.Lock()
+		entries = append(entries, entry)
+		mu.Unlock()
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	return entries, err
+	// Comparing paths with the separators as NUL, which sorts before any other
+	// byte, lists each directory's contents right after it, as WalkDir does
+	sep := string(filepath.Separator)
+	sort.Slice(entries, func(i, j int) bool {
+		return strings.ReplaceAll(entries[i].FullPath, sep, "\x00") < strings.ReplaceAll(entries[j].FullPath, sep, "\x00")
+	})
+	return entries, failures.err()
 }
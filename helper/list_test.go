@@ -1,6 +1,7 @@
 package helper
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -41,24 +42,6 @@ func TestGetDirectoryFromArgs(t *testing.T) {
 	}
 }
 
-// TestFormatSize checks formatting at bytes/KB/MB/GB boundaries.
-func TestFormatSize(t *testing.T) {
-	t.Parallel()
-
-	if got := FormatSize(500); !strings.Contains(got, "500") || !strings.Contains(got, "Bytes") {
-		t.Fatalf("unexpected FormatSize for 500: %s", got)
-	}
-	if got := FormatSize(1024); got != "1.00 KB" {
-		t.Fatalf("expected 1.00 KB for 1024, got %s", got)
-	}
-	if got := FormatSize(1024 * 1024); got != "1.00 MB" {
-		t.Fatalf("expected 1.00 MB for 1048576, got %s", got)
-	}
-	if got := FormatSize(1024 * 1024 * 1024); got != "1.00 GB" {
-		t.Fatalf("expected 1.00 GB for 1073741824, got %s", got)
-	}
-}
-
 // TestGetDirSize creates a nested directory with files and ensures sizes are summed.
 func TestGetDirSize(t *testing.T) {
 	t.Parallel()
@@ -90,31 +73,6 @@ func TestGetDirSize(t *testing.T) {
 	}
 }
 
-// TestIsParentHidden ensures that hidden parent directories are detected.
-func TestIsParentHidden(t *testing.T) {
-	t.Parallel()
-
-	td := t.TempDir()
-	// create a hidden parent inside td
-	hidden := filepath.Join(td, ".hidden")
-	nested := filepath.Join(hidden, "nested")
-	if err := os.MkdirAll(nested, 0o755); err != nil {
-		t.Fatalf("mkdir hidden nested failed: %v", err)
-	}
-	// currentPath is nested; basePath is td -> isParentHidden should be true
-	if !isParentHidden(nested, td) {
-		t.Fatalf("expected isParentHidden to be true for %q under %q", nested, td)
-	}
-	// when no hidden parents, should be false
-	normal := filepath.Join(td, "a", "b")
-	if err := os.MkdirAll(normal, 0o755); err != nil {
-		t.Fatalf("mkdir normal failed: %v", err)
-	}
-	if isParentHidden(normal, td) {
-		t.Fatalf("expected isParentHidden to be false for %q under %q", normal, td)
-	}
-}
-
 // TestGetFileListing verifies hidden inclusion/exclusion and entries produced.
 func TestGetFileListing(t *testing.T) {
 	t.Parallel()
@@ -196,7 +154,7 @@ func TestGetDirectoryTree(t *testing.T) {
 	}
 
 	// exclude hidden
-	tree, err := GetDirectoryTree(td, false)
+	tree, err := GetDirectoryTree(context.Background(), td, false, false)
 	if err != nil {
 		t.Fatalf("GetDirectoryTree returned error: %v", err)
 	}
@@ -208,7 +166,7 @@ func TestGetDirectoryTree(t *testing.T) {
 	}
 
 	// include hidden
-	tree2, err := GetDirectoryTree(td, true)
+	tree2, err := GetDirectoryTree(context.Background(), td, true, false)
 	if err != nil {
 		t.Fatalf("GetDirectoryTree returned error: %v", err)
 	}
@@ -0,0 +1,90 @@
+package helper
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// MatchOptions selects how a search query is interpreted.
+type MatchOptions struct {
+	// Regex treats the query as an RE2 regular expression.
+	Regex bool
+	// Glob treats the query as a glob where "**" matches any number of directories.
+	Glob bool
+	// IgnoreCase matches letters regardless of case.
+	IgnoreCase bool
+	// WholeName requires the query to match the entire text rather than a part of it.
+	WholeName bool
+}
+
+// Matcher finds occurrences of a query in text. It is shared by the name
+// and content searches so both interpret queries the same way.
+type Matcher interface {
+	// MatchString reports whether text contains a match.
+	MatchString(text string) bool
+	// FindAll returns the byte ranges of every non-overlapping match in text.
+	FindAll(text string) [][]int
+}
+
+// literalMatcher matches a plain substring.
+type literalMatcher struct {
+	query string
+}
+
+func (m literalMatcher) MatchString(text string) bool {
+	return strings.Contains(text, m.query)
+}
+
+func (m literalMatcher) FindAll(text string) [][]int {
+	return LiteralSpans(text, m.query)
+}
+
+// regexpMatcher matches a compiled regular expression.
+type regexpMatcher struct {
+	re *regexp.Regexp
+}
+
+func (m regexpMatcher) MatchString(text string) bool {
+	return m.re.MatchString(text)
+}
+
+func (m regexpMatcher) FindAll(text string) [][]int {
+	return m.re.FindAllStringIndex(text, -1)
+}
+
+// NewMatcher builds a Matcher for query. Glob patterns without a slash match
+// the last path element at any depth, like patterns in .gitignore files.
+func NewMatcher(query string, opts MatchOptions) (Matcher, error) {
+	if opts.Regex && opts.Glob {
+		return nil, errors.New("regex and glob matching cannot be combined")
+	}
+	if !opts.Regex && !opts.Glob && !opts.IgnoreCase && !opts.WholeName {
+		return literalMatcher{query: query}, nil
+	}
+
+	var expr string
+	switch {
+	case opts.Regex:
+		expr = query
+	case opts.Glob:
+		if !strings.Contains(query, "/") {
+			query = "**/" + query
+		}
+		expr = globToRegexp(strings.TrimPrefix(query, "/"))
+	default:
+		expr = regexp.QuoteMeta(query)
+	}
+	// Globs always describe the whole path
+	if opts.WholeName || opts.Glob {
+		expr = "^(?:" + expr + ")$"
+	}
+	if opts.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return regexpMatcher{re: re}, nil
+}
@@ -0,0 +1,88 @@
+package helper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestNewMatcher checks literal, regex, glob, case and whole-name matching.
+func TestNewMatcher(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		query string
+		opts  MatchOptions
+		text  string
+		want  bool
+	}{
+		{"report", MatchOptions{}, "annual-report.pdf", true},
+		{"Report", MatchOptions{}, "annual-report.pdf", false},
+		{"Report", MatchOptions{IgnoreCase: true}, "annual-report.pdf", true},
+		{"report", MatchOptions{WholeName: true}, "annual-report.pdf", false},
+		{"a.c", MatchOptions{WholeName: true}, "abc", false},
+		{`^IMG_\d+\.jpg$`, MatchOptions{Regex: true}, "IMG_0042.jpg", true},
+		{`^IMG_\d+\.jpg$`, MatchOptions{Regex: true}, "img_0042.jpg", false},
+		{"*.go", MatchOptions{Glob: true}, "cmd/root.go", true},
+		{"src/**/*.go", MatchOptions{Glob: true}, "src/a/b/c.go", true},
+		{"src/**/*.go", MatchOptions{Glob: true}, "lib/a.go", false},
+		{"*.GO", MatchOptions{Glob: true, IgnoreCase: true}, "main.go", true},
+	}
+	for _, c := range cases {
+		m, err := NewMatcher(c.query, c.opts)
+		if err != nil {
+			t.Fatalf("NewMatcher(%q) returned error: %v", c.query, err)
+		}
+		if got := m.MatchString(c.text); got != c.want {
+			t.Fatalf("NewMatcher(%q, %+v).MatchString(%q) = %v, want %v", c.query, c.opts, c.text, got, c.want)
+		}
+	}
+
+	if _, err := NewMatcher("x", MatchOptions{Regex: true, Glob: true}); err == nil {
+		t.Fatalf("expected error when combining regex and glob")
+	}
+	if _, err := NewMatcher("(", MatchOptions{Regex: true}); err == nil {
+		t.Fatalf("expected error for invalid regex")
+	}
+}
+
+// TestMatcherFindAll ensures match spans are reported for highlighting.
+func TestMatcherFindAll(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewMatcher("ab", MatchOptions{IgnoreCase: true})
+	if err != nil {
+		t.Fatalf("NewMatcher returned error: %v", err)
+	}
+	spans := m.FindAll("xAByab")
+	if len(spans) != 2 || spans[0][0] != 1 || spans[1][0] != 4 {
+		t.Fatalf("unexpected spans: %v", spans)
+	}
+}
+
+// TestSearchByNameFullPath verifies that --full-path matches directory names too.
+func TestSearchByNameFullPath(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(td, "vendor"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(td, "vendor", "lib.go"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	results, err := SearchByName(context.Background(), "vendor/", td, SearchOptions{})
+	if err != nil || len(results) != 0 {
+		t.Fatalf("expected no base-name match, got %v (err %v)", results, err)
+	}
+	results, err = SearchByName(context.Background(), "vendor/", td, SearchOptions{FullPath: true})
+	if err != nil {
+		t.Fatalf("SearchByName returned error: %v", err)
+	}
+	if len(results) != 1 || !strings.HasSuffix(results[0], "lib.go") {
+		t.Fatalf("expected vendor/lib.go, got %v", results)
+	}
+}
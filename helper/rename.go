@@ -0,0 +1,201 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RenameOp renames the file at From to To.
+type RenameOp struct {
+	From string
+	To   string
+}
+
+// Substitution is a sed-style s/pattern/replacement/flags expression applied to file names.
+type Substitution struct {
+	re          *regexp.Regexp
+	replacement string
+	global      bool
+}
+
+// sedGroupRef matches sed-style \1 group references in a replacement.
+var sedGroupRef = regexp.MustCompile(`\\(\d)`)
+
+// IsSubstitution reports whether expr looks like a s/pattern/replacement/ expression.
+func IsSubstitution(expr string) bool {
+	if len(expr) < 4 || expr[0] != 's' {
+		return false
+	}
+	delim := expr[1]
+	if delim == '\\' || delim == ' ' || (delim >= 'a' && delim <= 'z') || (delim >= 'A' && delim <= 'Z') || (delim >= '0' && delim <= '9') {
+		return false
+	}
+	_, err := splitSubstitution(expr)
+	return err == nil
+}
+
+// splitSubstitution splits expr into its pattern, replacement and flags. The
+// delimiter is the character after the s, and may be escaped with a backslash.
+func splitSubstitution(expr string) ([]string, error) {
+	delim := expr[1]
+	var parts []string
+	var part strings.Builder
+	for i := 2; i < len(expr); i++ {
+		c := expr[i]
+		if c == '\\' && i+1 < len(expr) && expr[i+1] == delim {
+			part.WriteByte(delim)
+			i++
+			continue
+		}
+		if c == delim && len(parts) < 2 {
+			parts = append(parts, part.String())
+			part.Reset()
+			continue
+		}
+		part.WriteByte(c)
+	}
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid substitution %q (expected s/pattern/replacement/)", expr)
+	}
+	return append(parts, part.String()), nil
+}
+
+// ParseSubstitution parses a s/pattern/replacement/flags expression. The pattern is
+// an RE2 regular expression and the replacement may refer to groups as $1, ${name}
+// or \1. The g flag replaces every match instead of the first and i ignores case.
+func ParseSubstitution(expr string) (*Substitution, error) {
+	if !IsSubstitution(expr) {
+		return nil, fmt.Errorf("invalid substitution %q (expected s/pattern/replacement/)", expr)
+	}
+	parts, _ := splitSubstitution(expr)
+	pattern, replacement, flags := parts[0], parts[1], parts[2]
+
+	sub := &Substitution{replacement: sedGroupRef.ReplaceAllString(replacement, "$${$1}")}
+	for _, flag := range flags {
+		switch flag {
+		case 'g':
+			sub.global = true
+		case 'i':
+			pattern = "(?i)" + pattern
+		default:
+			return nil, fmt.Errorf("invalid substitution flag %q (expected g or i)", flag)
+		}
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	sub.re = re
+	return sub, nil
+}
+
+// Apply returns name with the substitution applied.
+func (s *Substitution) Apply(name string) string {
+	if s.global {
+		return s.re.ReplaceAllString(name, s.replacement)
+	}
+	loc := s.re.FindStringSubmatchIndex(name)
+	if loc == nil {
+		return name
+	}
+	expanded := s.re.ExpandString(nil, s.replacement, name, loc)
+	return name[:loc[0]] + string(expanded) + name[loc[1]:]
+}
+
+// ErrPathSeparator is returned for a new name that would move a file to another directory.
+var ErrPathSeparator = errors.New("new name contains a path separator")
+
+// RenameTarget returns the path that renaming path to name gives. Unless
+// allowMove is true, name must be a plain file name; otherwise it may be a
+// path, which is taken relative to path's directory unless it is absolute.
+func RenameTarget(path, name string, allowMove bool) (string, error) {
+	if name == "" || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid new name %q", name)
+	}
+	if strings.ContainsRune(name, '/') || strings.ContainsRune(name, filepath.Separator) {
+		if !allowMove {
+			return "", fmt.Errorf("%w: %s", ErrPathSeparator, name)
+		}
+		if filepath.IsAbs(name) {
+			return filepath.Clean(name), nil
+		}
+	}
+	return filepath.Join(filepath.Dir(path), name), nil
+}
+
+// PlanRenames builds the renames that give each path the name returned by
+// newName, which gets the path and its index in paths. Names are resolved
+// with RenameTarget, and paths whose name doesn't change are left out.
+func PlanRenames(paths []string, allowMove bool, newName func(path string, index int) (string, error)) ([]RenameOp, error) {
+	var ops []RenameOp
+	for i, path := range paths {
+		name, err := newName(path, i)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		to, err := RenameTarget(path, name, allowMove)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if to == filepath.Clean(path) {
+			continue
+		}
+		ops = append(ops, RenameOp{From: path, To: to})
+	}
+	return ops, nil
+}
+
+// CollisionError lists the renames that can't be done without losing a file.
+type CollisionError struct {
+	Collisions []string
+}
+
+func (e *CollisionError) Error() string {
+	if len(e.Collisions) == 1 {
+		return e.Collisions[0]
+	}
+	return fmt.Sprintf("%d name collisions:\n  %s", len(e.Collisions), strings.Join(e.Collisions, "\n  "))
+}
+
+// CheckRenames reports a *CollisionError if two renames share a target, or if
+// a target already exists and overwrite is false. Targets freed by another
+// rename or a deletion in the same batch don't count as existing, and neither
+// does the source itself in a case-only rename on a case-insensitive
+// filesystem. Ops with an empty To are deletions.
+func CheckRenames(ops []RenameOp, overwrite bool) error {
+	freed := map[string]bool{}
+	for _, op := range ops {
+		freed[filepath.Clean(op.From)] = true
+	}
+	var collisions []string
+	targets := map[string]string{}
+	for _, op := range ops {
+		if op.To == "" {
+			continue
+		}
+		to := filepath.Clean(op.To)
+		if other, ok := targets[to]; ok {
+			collisions = append(collisions, fmt.Sprintf("%s and %s would both be renamed to %s", other, op.From, op.To))
+			continue
+		}
+		targets[to] = op.From
+		if overwrite || freed[to] || isCaseOnlyRename(op.From, op.To) {
+			continue
+		}
+		if _, err := os.Lstat(op.To); err == nil {
+			collisions = append(collisions, fmt.Sprintf("%s would overwrite the existing %s", op.From, op.To))
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if len(collisions) > 0 {
+		sort.Strings(collisions)
+		return &CollisionError{Collisions: collisions}
+	}
+	return nil
+}
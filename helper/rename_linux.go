@@ -0,0 +1,35 @@
+//go:build linux
+
+package helper
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// Flags for renameat2(2).
+const (
+	renameFlagNoReplace = unix.RENAME_NOREPLACE
+	renameFlagExchange  = unix.RENAME_EXCHANGE
+)
+
+// sysRenameat2 is the system call behind renameat2, a variable so tests can
+// stand in for filesystems that don't support it.
+var sysRenameat2 = unix.Renameat2
+
+// renameat2 calls renameat2(2) with flags, paths being relative to the
+// working directory. It returns errNoRenameat2 if the kernel or the
+// filesystem doesn't support the call or the flags: NFS, vfat, exfat and
+// many FUSE filesystems reject the flags with EINVAL.
+func renameat2(from, to string, flags uint) error {
+	err := sysRenameat2(unix.AT_FDCWD, from, unix.AT_FDCWD, to, flags)
+	if errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EOPNOTSUPP) || errors.Is(err, unix.EINVAL) {
+		return errNoRenameat2
+	}
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: from, New: to, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+//go:build linux
+
+package helper
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// TestRenameat2Unsupported stands in for a filesystem that rejects the
+// renameat2 flags with EINVAL, like NFS or vfat, and checks that renames,
+// copies and swaps fall back to the portable ways.
+func TestRenameat2Unsupported(t *testing.T) {
+	defer func() { sysRenameat2 = unix.Renameat2 }()
+	sysRenameat2 = func(int, string, int, string, uint) error { return unix.EINVAL }
+
+	td := t.TempDir()
+	path := func(name string) string { return filepath.Join(td, name) }
+	writeNamedFiles(t, td, "a", "b", "taken")
+
+	if err := renameNoReplace(path("a"), path("c")); err != nil {
+		t.Fatalf("renameNoReplace returned error: %v", err)
+	}
+	if err := renameNoReplace(path("c"), path("taken")); !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("expected the existing file to be kept, got %v", err)
+	}
+	if err := SwapPaths(path("b"), path("c")); err != nil {
+		t.Fatalf("SwapPaths returned error: %v", err)
+	}
+	checkContents(t, td, map[string]string{"b": "a", "c": "b", "taken": "taken"})
+
+	dst := t.TempDir()
+	if err := CopyFile(path("taken"), dst, false, false); err != nil {
+		t.Fatalf("CopyFile returned error: %v", err)
+	}
+	checkContents(t, dst, map[string]string{"taken": "taken"})
+}
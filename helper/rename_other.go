@@ -0,0 +1,14 @@
+//go:build !linux
+
+package helper
+
+// Flags for renameat2(2), which only Linux has.
+const (
+	renameFlagNoReplace = 1 << 0
+	renameFlagExchange  = 1 << 1
+)
+
+// renameat2 is unavailable on this platform, so callers fall back to portable renames.
+func renameat2(from, to string, flags uint) error {
+	return errNoRenameat2
+}
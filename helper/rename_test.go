@@ -0,0 +1,127 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestParseSubstitution checks delimiters, flags and group references.
+func TestParseSubstitution(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		expr, in, want string
+	}{
+		{`s/IMG_(\d+)/photo-$1/`, "IMG_0042.jpg", "photo-0042.jpg"},
+		{`s/a/b/`, "banana", "bbnana"},
+		{`s/a/b/g`, "banana", "bbnbnb"},
+		{`s/JPG$/jpg/i`, "x.Jpg", "x.jpg"},
+		{`s|(\w+)-(\w+)|\2-\1|`, "left-right.txt", "right-left.txt"},
+		{`s/\//_/g`, "a/b", "a_b"},
+		{`s/x//`, "axb", "ab"},
+	}
+	for _, c := range cases {
+		sub, err := ParseSubstitution(c.expr)
+		if err != nil {
+			t.Fatalf("ParseSubstitution(%q) returned error: %v", c.expr, err)
+		}
+		if got := sub.Apply(c.in); got != c.want {
+			t.Fatalf("%q applied to %q = %q, want %q", c.expr, c.in, got, c.want)
+		}
+	}
+	for _, expr := range []string{"s/a/b", "sab", "file.txt", "s/(/x/", "s/a/b/q"} {
+		if _, err := ParseSubstitution(expr); err == nil {
+			t.Fatalf("expected error for %q", expr)
+		}
+	}
+}
+
+// TestCheckRenames verifies collisions within the batch and against existing files.
+func TestCheckRenames(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "taken.txt"} {
+		if err := os.WriteFile(filepath.Join(td, name), []byte(name), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	path := func(name string) string { return filepath.Join(td, name) }
+
+	if err := CheckRenames([]RenameOp{{path("a.txt"), path("c.txt")}}, false); err != nil {
+		t.Fatalf("expected a free target to pass, got %v", err)
+	}
+
+	err := CheckRenames([]RenameOp{{path("a.txt"), path("same.txt")}, {path("b.txt"), path("same.txt")}}, false)
+	var collision *CollisionError
+	if !errors.As(err, &collision) || len(collision.Collisions) != 1 {
+		t.Fatalf("expected a collision inside the batch, got %v", err)
+	}
+
+	err = CheckRenames([]RenameOp{{path("a.txt"), path("taken.txt")}}, false)
+	if !errors.As(err, &collision) {
+		t.Fatalf("expected a collision with an existing file, got %v", err)
+	}
+	if err := CheckRenames([]RenameOp{{path("a.txt"), path("taken.txt")}}, true); err != nil {
+		t.Fatalf("expected overwrite to allow existing targets, got %v", err)
+	}
+}
+
+// TestApplyRenamesSwapAndCycle verifies that swaps and cycles go through temporary names.
+func TestApplyRenamesSwapAndCycle(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	path := func(name string) string { return filepath.Join(td, name) }
+	for _, name := range []string{"a", "b", "c", "gone"} {
+		if err := os.WriteFile(path(name), []byte(name), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	// a → b → c → a, a deletion, and a move into a new directory
+	ops := []RenameOp{{path("a"), path("b")}, {path("b"), path("c")}, {path("c"), path("sub/a")}, {path("gone"), ""}}
+	if err := CheckRenames(ops, false); err != nil {
+		t.Fatalf("CheckRenames returned error: %v", err)
+	}
+	ApplyRenames(context.Background(), ops, false, true, func(op RenameOp, err error) {
+		if err != nil {
+			t.Fatalf("renaming %s to %s failed: %v", op.From, op.To, err)
+		}
+	})
+
+	for name, want := range map[string]string{"b": "a", "c": "b", "sub/a": "c"} {
+		data, err := os.ReadFile(path(name))
+		if err != nil || string(data) != want {
+			t.Fatalf("%s holds %q (%v), want %q", name, data, err, want)
+		}
+	}
+	entries, _ := os.ReadDir(td)
+	if len(entries) != 3 {
+		t.Fatalf("expected only b, c and sub to remain, got %v", entries)
+	}
+}
+
+// TestRenameTarget checks plain names, rejected separators and allowed moves.
+func TestRenameTarget(t *testing.T) {
+	t.Parallel()
+
+	src := filepath.Join("dir", "a.txt")
+	if got, err := RenameTarget(src, "b.txt", false); err != nil || got != filepath.Join("dir", "b.txt") {
+		t.Fatalf("RenameTarget(b.txt) = %q, %v", got, err)
+	}
+	if _, err := RenameTarget(src, "sub/b.txt", false); !errors.Is(err, ErrPathSeparator) {
+		t.Fatalf("expected ErrPathSeparator, got %v", err)
+	}
+	if got, err := RenameTarget(src, "../b.txt", true); err != nil || got != "b.txt" {
+		t.Fatalf("RenameTarget(../b.txt) = %q, %v", got, err)
+	}
+	for _, name := range []string{"", ".", ".."} {
+		if _, err := RenameTarget(src, name, true); err == nil {
+			t.Fatalf("expected %q to be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package helper
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// errRenameRolledBack is reported for renames undone after another one in the batch failed.
+var errRenameRolledBack = errors.New("undone because another rename in the batch failed")
+
+// errNoRenameat2 is returned by renameat2 where it can't be used.
+var errNoRenameat2 = errors.New("renameat2 is not supported")
+
+// renameNoReplace renames from to to, failing with an error wrapping
+// fs.ErrExist if to exists. It uses renameat2 with RENAME_NOREPLACE where
+// available, and linkRename otherwise.
+func renameNoReplace(from, to string) error {
+	err := renameat2(from, to, renameFlagNoReplace)
+	if errors.Is(err, errNoRenameat2) {
+		return linkRename(from, to)
+	}
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%w: %s", fs.ErrExist, to)
+	}
+	return err
+}
+
+// linkRename renames from to to with a hard link, which can't replace a file,
+// followed by removing from. Directories and filesystems without hard links
+// fall back to checking for to just before renaming.
+func linkRename(from, to string) error {
+	if err := os.Link(from, to); err == nil {
+		if err := os.Remove(from); err != nil {
+			_ = os.Remove(to)
+			return err
+		}
+		return nil
+	} else if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%w: %s", fs.ErrExist, to)
+	}
+	if _, err := os.Lstat(to); err == nil {
+		return fmt.Errorf("%w: %s", fs.ErrExist, to)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return os.Rename(from, to)
+}
+
+// renameStep is a single os.Rename carried out while applying a batch.
+type renameStep struct {
+	from, to string
+	// op is the index of the RenameOp the step belongs to.
+	op int
+	// displaced is set for a file moved out of the way of an overwriting rename.
+	displaced bool
+}
+
+// tempName returns an unused hidden name next to path.
+func tempName(path string) (string, error) {
+	for {
+		suffix := make([]byte, 6)
+		if _, err := rand.Read(suffix); err != nil {
+			return "", err
+		}
+		name := filepath.Join(filepath.Dir(path), fmt.Sprintf(".f-rename-%x", suffix))
+		if _, err := os.Lstat(name); errors.Is(err, os.ErrNotExist) {
+			return name, nil
+		}
+	}
+}
+
+// isTempName reports whether path was made by tempName.
+func isTempName(path string) bool {
+	return strings.HasPrefix(filepath.Base(path), ".f-rename-")
+}
+
+// isCaseOnlyRename reports whether renaming from to to only changes the case
+// of the name on a case-insensitive filesystem. It is a variable so tests on
+// case-sensitive filesystems can stand in for one.
+var isCaseOnlyRename = caseOnlyRename
+
+// caseOnlyRename reports whether renaming from to to only changes the case of
+// the name on a case-insensitive filesystem, where to already resolves to
+// from. A hard link that differs only in case on a case-sensitive filesystem
+// is listed under its own name, so it doesn't count.
+func caseOnlyRename(from, to string) bool {
+	if filepath.Clean(from) == filepath.Clean(to) || !strings.EqualFold(filepath.Clean(from), filepath.Clean(to)) {
+		return false
+	}
+	fromInfo, err := os.Lstat(from)
+	if err != nil {
+		return false
+	}
+	toInfo, err := os.Lstat(to)
+	if err != nil || !os.SameFile(fromInfo, toInfo) {
+		return false
+	}
+	entries, err := os.ReadDir(filepath.Dir(to))
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if entry.Name() == filepath.Base(to) {
+			return false
+		}
+	}
+	return true
+}
+
+// orderRenames turns ops into steps that never rename onto a file that still
+// has to move. Each rename depends on the rename whose source is its target,
+// so the renames form chains and cycles: chains are done from the free end
+// back, and each cycle is broken by moving one file to a temporary name and
+// moving it to its target last. Deletions are moved to temporary names first
+// and are deleted by the caller once every rename has worked. Case-only renames
+// on case-insensitive filesystems also go through a temporary name, since the
+// target name already refers to the file.
+func orderRenames(ops []RenameOp) ([]renameStep, error) {
+	bySource := map[string]int{}
+	caseTemps := map[int]string{}
+	for i, op := range ops {
+		bySource[filepath.Clean(op.From)] = i
+		if op.To != "" && isCaseOnlyRename(op.From, op.To) {
+			temp, err := tempName(op.From)
+			if err != nil {
+				return nil, err
+			}
+			caseTemps[i] = temp
+		}
+	}
+	// waiter[j] is the rename whose target is the source of rename j
+	waiter := map[int]int{}
+	blocked := make([]bool, len(ops))
+	for i, op := range ops {
+		if op.To == "" {
+			continue
+		}
+		if j, ok := bySource[filepath.Clean(op.To)]; ok && j != i {
+			waiter[j] = i
+			blocked[i] = true
+		}
+	}
+
+	var steps []renameStep
+	done := make([]bool, len(ops))
+	// follow does rename i and then every rename that was waiting for it,
+	// stopping at stop, where a cycle closes.
+	follow := func(i, stop int) {
+		for i != stop && !done[i] {
+			if temp, ok := caseTemps[i]; ok {
+				steps = append(steps, renameStep{from: ops[i].From, to: temp, op: i}, renameStep{from: temp, to: ops[i].To, op: i})
+			} else {
+				steps = append(steps, renameStep{from: ops[i].From, to: ops[i].To, op: i})
+			}
+			done[i] = true
+			next, ok := waiter[i]
+			if !ok {
+				return
+			}
+			i = next
+		}
+	}
+
+	for i, op := range ops {
+		if op.To == "" {
+			temp, err := tempName(op.From)
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, renameStep{from: op.From, to: temp, op: i})
+			done[i] = true
+			if next, ok := waiter[i]; ok {
+				follow(next, -1)
+			}
+		}
+	}
+	for i, op := range ops {
+		if filepath.Clean(op.From) == filepath.Clean(op.To) {
+			// Renaming a file to its own name leaves it where it is
+			done[i] = true
+			continue
+		}
+		if !blocked[i] {
+			follow(i, -1)
+		}
+	}
+	// Whatever is left is part of a cycle
+	for i, op := range ops {
+		if done[i] {
+			continue
+		}
+		temp, err := tempName(op.From)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, renameStep{from: op.From, to: temp, op: i})
+		done[i] = true
+		follow(waiter[i], i)
+		steps = append(steps, renameStep{from: temp, to: op.To, op: i})
+	}
+	return steps, nil
+}
+
+// renameRun carries out rename steps and remembers enough to undo them.
+type renameRun struct {
+	overwrite bool
+	// createDirs allows making the missing parent directories of targets.
+	createDirs bool
+	done       []renameStep
+	// created are the directories made for targets, parents first.
+	created []string
+	// displaced are the temporary names of files replaced with overwrite.
+	displaced []string
+}
+
+// mkdirAll creates dir and any missing parents, remembering the ones it made.
+func (r *renameRun) mkdirAll(dir string) error {
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Lstat(d); err == nil {
+			break
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		missing = append(missing, d)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := os.Mkdir(missing[i], os.ModePerm); err != nil && !errors.Is(err, os.ErrExist) {
+			return err
+		}
+		r.created = append(r.created, missing[i])
+	}
+	return nil
+}
+
+// do carries out step. A file already at the target is refused unless
+// overwrite is set, in which case it is moved aside so the rename can be undone.
+func (r *renameRun) do(step renameStep) error {
+	if r.createDirs {
+		if err := r.mkdirAll(filepath.Dir(step.to)); err != nil {
+			return err
+		}
+	}
+	// Temporary names are never meant to replace anything
+	if !r.overwrite || isTempName(step.to) {
+		if err := renameNoReplace(step.from, step.to); err != nil {
+			return err
+		}
+		r.done = append(r.done, step)
+		return nil
+	}
+	if info, err := os.Lstat(step.to); err == nil && !info.IsDir() {
+		temp, err := tempName(step.to)
+		if err != nil {
+			return err
+		}
+		if err := renameNoReplace(step.to, temp); err != nil {
+			return err
+		}
+		r.done = append(r.done, renameStep{from: step.to, to: temp, op: step.op, displaced: true})
+		r.displaced = append(r.displaced, temp)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	// A directory target is left to os.Rename, which only replaces empty ones
+	if err := os.Rename(step.from, step.to); err != nil {
+		return err
+	}
+	r.done = append(r.done, step)
+	return nil
+}
+
+// rollback undoes the steps done so far, newest first, and removes the
+// directories made for them. It returns the errors of the ops whose files,
+// including the ones they displaced or moved to a temporary name, could not
+// be put back, each naming where the file was left.
+func (r *renameRun) rollback() map[int]error {
+	failed := map[int]error{}
+	// stuck holds the names whose files couldn't be moved back to them, so the
+	// earlier steps of those files aren't undone
+	stuck := map[string]bool{}
+	for i := len(r.done) - 1; i >= 0; i-- {
+		step := r.done[i]
+		if stuck[step.to] {
+			stuck[step.from] = true
+			continue
+		}
+		if err := renameNoReplace(step.to, step.from); err != nil {
+			stuck[step.from] = true
+			what := "could not be undone"
+			if step.displaced {
+				what = fmt.Sprintf("could not put back the overwritten %s", step.from)
+			}
+			err = fmt.Errorf("%s after another rename failed: %w (the file was left at %s)", what, err, step.to)
+			failed[step.op] = errors.Join(failed[step.op], err)
+		}
+	}
+	for i := len(r.created) - 1; i >= 0; i-- {
+		_ = os.Remove(r.created[i])
+	}
+	return failed
+}
+
+// ApplyRenames carries out ops and calls report with the result of each, in
+// order. Ops with an empty To are deletions. The renames are ordered so chains
+// like a → b, b → c work without temporary names, and swaps and cycles go
+// through one temporary name each. The batch is all or nothing: if a rename
+// fails, the ones already done are undone, and files are only deleted, or
+// replaced with overwrite, once every rename has worked. If ctx is cancelled
+// before the renames are done, they are undone too and the ops that were put
+// back report ctx's error. Missing parent directories of targets are only
+// made with createDirs; otherwise such a rename fails.
+func ApplyRenames(ctx context.Context, ops []RenameOp, overwrite bool, createDirs bool, report func(op RenameOp, err error)) {
+	results := make([]error, len(ops))
+	defer func() {
+		for i, op := range ops {
+			report(op, results[i])
+		}
+	}()
+
+	steps, err := orderRenames(ops)
+	if err != nil {
+		for i := range results {
+			results[i] = err
+		}
+		return
+	}
+	run := &renameRun{overwrite: overwrite, createDirs: createDirs}
+	for _, step := range steps {
+		if err := ctx.Err(); err != nil {
+			failed := run.rollback()
+			for i := range results {
+				results[i] = err
+				if err, ok := failed[i]; ok {
+					results[i] = err
+				}
+			}
+			return
+		}
+		if err := run.do(step); err != nil {
+			failed := run.rollback()
+			for i := range results {
+				results[i] = errRenameRolledBack
+				if err, ok := failed[i]; ok {
+					results[i] = err
+				}
+			}
+			results[step.op] = errors.Join(err, failed[step.op])
+			return
+		}
+	}
+
+	// Deletions were moved to a temporary name by their only step
+	for _, step := range run.done {
+		if step.displaced || ops[step.op].To != "" {
+			continue
+		}
+		if err := deletePath(step.to); err != nil {
+			results[step.op] = fmt.Errorf("%w (the file was left at %s)", err, step.to)
+		}
+	}
+	for _, temp := range run.displaced {
+		_ = deletePath(temp)
+	}
+}
+
+// deletePath deletes the file or directory at path.
+func deletePath(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return DeleteDirectory(path)
+	}
+	return DeleteFile(path)
+}
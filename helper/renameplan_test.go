@@ -0,0 +1,291 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeNamedFiles creates a file holding its own name for each name in dir.
+func writeNamedFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+}
+
+// checkContents fails unless each file in dir holds the given text.
+func checkContents(t *testing.T, dir string, want map[string]string) {
+	t.Helper()
+	for name, text := range want {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(data) != text {
+			t.Fatalf("%s holds %q (%v), want %q", name, data, err, text)
+		}
+	}
+}
+
+// TestOrderRenamesChain verifies that a shift like file1 → file2 → ... is done
+// from the free end back without temporary names.
+func TestOrderRenamesChain(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	var ops []RenameOp
+	want := map[string]string{}
+	for i := 1; i <= 9; i++ {
+		name := fmt.Sprintf("file%d", i)
+		writeNamedFiles(t, td, name)
+		ops = append(ops, RenameOp{filepath.Join(td, name), filepath.Join(td, fmt.Sprintf("file%d", i+1))})
+		want[fmt.Sprintf("file%d", i+1)] = name
+	}
+
+	steps, err := orderRenames(ops)
+	if err != nil {
+		t.Fatalf("orderRenames returned error: %v", err)
+	}
+	if len(steps) != len(ops) {
+		t.Fatalf("expected %d steps without temporary names, got %d", len(ops), len(steps))
+	}
+	if steps[0].op != 8 || steps[8].op != 0 {
+		t.Fatalf("expected the last file to move first, got %+v", steps)
+	}
+
+	ApplyRenames(context.Background(), ops, false, false, func(op RenameOp, err error) {
+		if err != nil {
+			t.Fatalf("renaming %s to %s failed: %v", op.From, op.To, err)
+		}
+	})
+	checkContents(t, td, want)
+}
+
+// TestOrderRenamesCycle verifies that a cycle uses a single temporary name.
+func TestOrderRenamesCycle(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	path := func(name string) string { return filepath.Join(td, name) }
+	ops := []RenameOp{{path("a"), path("b")}, {path("b"), path("c")}, {path("c"), path("a")}, {path("d"), path("e")}}
+	steps, err := orderRenames(ops)
+	if err != nil {
+		t.Fatalf("orderRenames returned error: %v", err)
+	}
+	temps := 0
+	for _, step := range steps {
+		if strings.HasPrefix(filepath.Base(step.to), ".f-rename-") {
+			temps++
+		}
+	}
+	if len(steps) != len(ops)+1 || temps != 1 {
+		t.Fatalf("expected one extra step through one temporary name, got %+v", steps)
+	}
+}
+
+// TestApplyRenamesRollback verifies that a failing rename undoes the rest of
+// the batch, including deletions and overwritten files.
+func TestApplyRenamesRollback(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	path := func(name string) string { return filepath.Join(td, name) }
+	writeNamedFiles(t, td, "a", "b", "c", "gone", "taken", "blocker")
+
+	// c can't be moved below a regular file, which fails after the others are done
+	ops := []RenameOp{{path("a"), path("b")}, {path("b"), path("taken")}, {path("gone"), ""}, {path("c"), path("blocker/c")}}
+	results := map[string]error{}
+	ApplyRenames(context.Background(), ops, true, false, func(op RenameOp, err error) {
+		results[op.From] = err
+	})
+
+	if results[path("c")] == nil {
+		t.Fatalf("expected the rename below a file to fail")
+	}
+	for _, name := range []string{"a", "b", "gone"} {
+		if !errors.Is(results[path(name)], errRenameRolledBack) {
+			t.Fatalf("expected %s to be rolled back, got %v", name, results[path(name)])
+		}
+	}
+	checkContents(t, td, map[string]string{"a": "a", "b": "b", "c": "c", "gone": "gone", "taken": "taken", "blocker": "blocker"})
+	entries, _ := os.ReadDir(td)
+	if len(entries) != 6 {
+		t.Fatalf("expected no temporary files to remain, got %v", entries)
+	}
+}
+
+// TestApplyRenamesCancel verifies that an interrupted batch is undone and
+// every op reports the cancellation.
+func TestApplyRenamesCancel(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	path := func(name string) string { return filepath.Join(td, name) }
+	writeNamedFiles(t, td, "a", "b")
+
+	// b → c is done first, then the batch is cancelled before a → b
+	ctx := &cancelAfter{Context: context.Background(), n: 2}
+	ops := []RenameOp{{path("a"), path("b")}, {path("b"), path("c")}}
+	ApplyRenames(ctx, ops, false, false, func(op RenameOp, err error) {
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected %s to report the cancellation, got %v", op.From, err)
+		}
+	})
+	checkContents(t, td, map[string]string{"a": "a", "b": "b"})
+	if _, err := os.Lstat(path("c")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the rename to c to be undone, got %v", err)
+	}
+}
+
+// TestApplyRenamesMissingDirectory verifies that targets in missing
+// directories fail unless directories may be created.
+func TestApplyRenamesMissingDirectory(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	writeNamedFiles(t, td, "a")
+	ops := []RenameOp{{filepath.Join(td, "a"), filepath.Join(td, "x", "y")}}
+	ApplyRenames(context.Background(), ops, false, false, func(op RenameOp, err error) {
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("expected the rename into a missing directory to fail, got %v", err)
+		}
+	})
+	if _, err := os.Lstat(filepath.Join(td, "x")); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected no directory to be made, got %v", err)
+	}
+
+	ApplyRenames(context.Background(), ops, false, true, func(op RenameOp, err error) {
+		if err != nil {
+			t.Fatalf("renaming %s to %s failed: %v", op.From, op.To, err)
+		}
+	})
+	checkContents(t, td, map[string]string{"x/y": "a"})
+}
+
+// TestRenameRunRollbackFailures verifies that every step that can't be undone
+// is reported with where its file was left, including displaced files.
+func TestRenameRunRollbackFailures(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	path := func(name string) string { return filepath.Join(td, name) }
+	writeNamedFiles(t, td, "blocker")
+	// Op 0 went through a temporary name and op 1 displaced a file, but the
+	// files have since gone and blocker takes the temporary name back
+	run := &renameRun{done: []renameStep{
+		{from: path("a"), to: path("blocker"), op: 0},
+		{from: path("blocker"), to: path("b"), op: 0},
+		{from: path("taken"), to: path(".f-rename-displaced"), op: 1, displaced: true},
+	}}
+	failed := run.rollback()
+
+	if len(failed) != 2 {
+		t.Fatalf("expected failures for both ops, got %v", failed)
+	}
+	if err := failed[0]; err == nil || !strings.Contains(err.Error(), "left at "+path("b")) || strings.Contains(err.Error(), "left at "+path("blocker")) {
+		t.Fatalf("expected op 0 to report the file left at b only, got %v", err)
+	}
+	if err := failed[1]; err == nil || !strings.Contains(err.Error(), "overwritten "+path("taken")) || !strings.Contains(err.Error(), "left at "+path(".f-rename-displaced")) {
+		t.Fatalf("expected op 1 to report the displaced file, got %v", err)
+	}
+	checkContents(t, td, map[string]string{"blocker": "blocker"})
+}
+
+// TestApplyRenamesOverwrite verifies that an overwritten file is removed once the batch succeeds.
+func TestApplyRenamesOverwrite(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	writeNamedFiles(t, td, "a", "taken")
+	ApplyRenames(context.Background(), []RenameOp{{filepath.Join(td, "a"), filepath.Join(td, "taken")}}, true, false, func(op RenameOp, err error) {
+		if err != nil {
+			t.Fatalf("renaming %s to %s failed: %v", op.From, op.To, err)
+		}
+	})
+	checkContents(t, td, map[string]string{"taken": "a"})
+	entries, _ := os.ReadDir(td)
+	if len(entries) != 1 {
+		t.Fatalf("expected only the renamed file to remain, got %v", entries)
+	}
+}
+
+// TestCaseOnlyRename verifies that a case-only rename goes through a temporary
+// name on a case-insensitive filesystem, and that a hard link differing in
+// case on a case-sensitive one still counts as an existing file.
+func TestCaseOnlyRename(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	writeNamedFiles(t, td, "readme.md")
+	from, to := filepath.Join(td, "readme.md"), filepath.Join(td, "README.md")
+
+	if _, err := os.Lstat(to); err != nil {
+		// Case-sensitive: a hard link named README.md is a different directory entry
+		if err := os.Link(from, to); err != nil {
+			t.Skipf("hard links unsupported: %v", err)
+		}
+		if caseOnlyRename(from, to) {
+			t.Fatalf("expected a hard link not to count as a case-only rename")
+		}
+		if err := CheckRenames([]RenameOp{{from, to}}, false); err == nil {
+			t.Fatalf("expected the hard link to collide")
+		}
+		return
+	}
+
+	ops := []RenameOp{{from, to}}
+	if err := CheckRenames(ops, false); err != nil {
+		t.Fatalf("CheckRenames returned error: %v", err)
+	}
+	steps, err := orderRenames(ops)
+	if err != nil || len(steps) != 2 {
+		t.Fatalf("expected two steps through a temporary name, got %+v, %v", steps, err)
+	}
+	ApplyRenames(context.Background(), ops, false, false, func(op RenameOp, err error) {
+		if err != nil {
+			t.Fatalf("renaming %s to %s failed: %v", op.From, op.To, err)
+		}
+	})
+	entries, _ := os.ReadDir(td)
+	if len(entries) != 1 || entries[0].Name() != "README.md" {
+		t.Fatalf("expected only README.md, got %v", entries)
+	}
+}
+
+// TestCaseOnlyRenameOrdering stands in for a case-insensitive filesystem to
+// check that a case-only rename goes through a temporary name, also when
+// another rename waits for its old name.
+func TestCaseOnlyRenameOrdering(t *testing.T) {
+	td := t.TempDir()
+	path := func(name string) string { return filepath.Join(td, name) }
+	writeNamedFiles(t, td, "readme.md", "other")
+
+	defer func() { isCaseOnlyRename = caseOnlyRename }()
+	isCaseOnlyRename = func(from, to string) bool {
+		return from == path("readme.md") && to == path("README.md")
+	}
+
+	ops := []RenameOp{{path("other"), path("readme.md")}, {path("readme.md"), path("README.md")}}
+	steps, err := orderRenames(ops)
+	if err != nil {
+		t.Fatalf("orderRenames returned error: %v", err)
+	}
+	if len(steps) != 3 || !isTempName(steps[0].to) || steps[1].from != steps[0].to || steps[2].op != 0 {
+		t.Fatalf("expected the case-only rename through a temporary name before the rename waiting for it, got %+v", steps)
+	}
+	ApplyRenames(context.Background(), ops, false, false, func(op RenameOp, err error) {
+		if err != nil {
+			t.Fatalf("renaming %s to %s failed: %v", op.From, op.To, err)
+		}
+	})
+	checkContents(t, td, map[string]string{"README.md": "readme.md", "readme.md": "other"})
+	entries, _ := os.ReadDir(td)
+	if len(entries) != 2 {
+		t.Fatalf("expected no temporary files to remain, got %v", entries)
+	}
+}
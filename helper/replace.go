@@ -0,0 +1,325 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// diffContext is the number of unchanged lines shown around each hunk.
+const diffContext = 3
+
+// Replacer rewrites the matches of a pattern within a line.
+type Replacer struct {
+	re      *regexp.Regexp
+	literal string
+	// replacement is expanded with capture groups like $1 and ${name} in regex mode
+	replacement string
+	regex       bool
+}
+
+// NewReplacer builds a Replacer for pattern. In regex mode the replacement may
+// refer to capture groups as $1 or ${name}; otherwise it is inserted as is.
+func NewReplacer(pattern, replacement string, opts MatchOptions) (*Replacer, error) {
+	if opts.Glob {
+		return nil, errors.New("glob patterns can't be used for replacing")
+	}
+	if pattern == "" {
+		return nil, errors.New("empty pattern")
+	}
+	r := &Replacer{literal: pattern, replacement: replacement, regex: opts.Regex}
+	if !opts.Regex && !opts.IgnoreCase {
+		return r, nil
+	}
+	expr := pattern
+	if !opts.Regex {
+		expr = regexp.QuoteMeta(pattern)
+	}
+	if opts.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	r.re = re
+	return r, nil
+}
+
+// Replace returns line with every match replaced and the number of matches.
+func (r *Replacer) Replace(line string) (string, int) {
+	if r.re == nil {
+		count := strings.Count(line, r.literal)
+		if count == 0 {
+			return line, 0
+		}
+		return strings.ReplaceAll(line, r.literal, r.replacement), count
+	}
+	count := len(r.re.FindAllStringIndex(line, -1))
+	if count == 0 {
+		return line, 0
+	}
+	if r.regex {
+		return r.re.ReplaceAllString(line, r.replacement), count
+	}
+	return r.re.ReplaceAllLiteralString(line, r.replacement), count
+}
+
+// LineEdit is a single changed line.
+type LineEdit struct {
+	// Line is the zero-based index of the line in the file.
+	Line int
+	// Old and New are the line's text without its line ending.
+	Old string
+	New string
+	// Matches is the number of replacements made in the line.
+	Matches int
+}
+
+// Hunk is a group of nearby line edits shown and confirmed together.
+type Hunk struct {
+	Edits []LineEdit
+}
+
+// FileEdit holds the planned replacements in one file. Hunks can be applied
+// selectively, and lines outside the applied hunks are written back unchanged,
+// line endings included.
+type FileEdit struct {
+	Path  string
+	Hunks []Hunk
+	// lines are the file's lines, each with its line ending.
+	lines []string
+	info  fs.FileInfo
+}
+
+// Matches returns the number of replacements in the selected hunks.
+func (f *FileEdit) Matches(selected []bool) int {
+	count := 0
+	for i, hunk := range f.Hunks {
+		if selected == nil || selected[i] {
+			for _, edit := range hunk.Edits {
+				count += edit.Matches
+			}
+		}
+	}
+	return count
+}
+
+// splitLines splits text into lines that keep their "\n" or "\r\n" ending.
+func splitLines(text string) []string {
+	var lines []string
+	for len(text) > 0 {
+		i := strings.IndexByte(text, '\n')
+		if i < 0 {
+			lines = append(lines, text)
+			break
+		}
+		lines = append(lines, text[:i+1])
+		text = text[i+1:]
+	}
+	return lines
+}
+
+// lineEnding returns the ending of line: "\r\n", "\n" or "" for a last line without one.
+func lineEnding(line string) string {
+	switch {
+	case strings.HasSuffix(line, "\r\n"):
+		return "\r\n"
+	case strings.HasSuffix(line, "\n"):
+		return "\n"
+	}
+	return ""
+}
+
+// PlanFileEdit reads path and returns the edits r would make, or nil if there
+// are none. Files that aren't valid UTF-8 text are left alone.
+func PlanFileEdit(path string, r *Replacer) (*FileEdit, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+		return nil, nil
+	}
+
+	edit := &FileEdit{Path: path, lines: splitLines(string(data)), info: info}
+	var edits []LineEdit
+	for i, line := range edit.lines {
+		text := strings.TrimSuffix(line, lineEnding(line))
+		replaced, count := r.Replace(text)
+		if count > 0 && replaced != text {
+			edits = append(edits, LineEdit{Line: i, Old: text, New: replaced, Matches: count})
+		}
+	}
+	if len(edits) == 0 {
+		return nil, nil
+	}
+	// Edits whose context would overlap share a hunk
+	for _, e := range edits {
+		if n := len(edit.Hunks); n > 0 {
+			last := edit.Hunks[n-1].Edits
+			if e.Line-last[len(last)-1].Line <= 2*diffContext+1 {
+				edit.Hunks[n-1].Edits = append(edit.Hunks[n-1].Edits, e)
+				continue
+			}
+		}
+		edit.Hunks = append(edit.Hunks, Hunk{Edits: []LineEdit{e}})
+	}
+	return edit, nil
+}
+
+// PlanReplace returns the edits r would make in every file under dir selected
+// by opts, sorted by path.
+// If some paths could not be read, the edits planned elsewhere are returned along with a *WalkError.
+func PlanReplace(ctx context.Context, dir string, r *Replacer, opts SearchOptions) ([]*FileEdit, error) {
+	var mu sync.Mutex
+	var edits []*FileEdit
+	err := walkFiles(ctx, dir, opts, func(path string, d fs.DirEntry) error {
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		edit, err := PlanFileEdit(path, r)
+		if err != nil || edit == nil {
+			return err
+		}
+		mu.Lock()
+		edits = append(edits, edit)
+		mu.Unlock()
+		return nil
+	})
+	sort.Slice(edits, func(i, j int) bool { return edits[i].Path < edits[j].Path })
+	return edits, notFound(err)
+}
+
+// content returns the file's text with the selected hunks applied. A nil
+// selection applies every hunk.
+func (f *FileEdit) content(selected []bool) string {
+	replaced := map[int]string{}
+	for i, hunk := range f.Hunks {
+		if selected == nil || selected[i] {
+			for _, e := range hunk.Edits {
+				replaced[e.Line] = e.New
+			}
+		}
+	}
+	var b strings.Builder
+	for i, line := range f.lines {
+		if text, ok := replaced[i]; ok {
+			b.WriteString(text + lineEnding(line))
+		} else {
+			b.WriteString(line)
+		}
+	}
+	return b.String()
+}
+
+// HunkDiff returns hunk i in unified diff format, numbering new lines as if
+// only the hunks before it that are selected had been applied.
+func (f *FileEdit) HunkDiff(i int, selected []bool) string {
+	// Replacements containing newlines shift the following lines
+	shift := 0
+	for j := 0; j < i; j++ {
+		if selected == nil || selected[j] {
+			for _, e := range f.Hunks[j].Edits {
+				shift += strings.Count(e.New, "\n") - strings.Count(e.Old, "\n")
+			}
+		}
+	}
+	edits := f.Hunks[i].Edits
+	start := max(edits[0].Line-diffContext, 0)
+	end := min(edits[len(edits)-1].Line+diffContext+1, len(f.lines))
+
+	var body strings.Builder
+	oldCount, newCount := 0, 0
+	next := 0
+	for line := start; line < end; line++ {
+		text := strings.TrimSuffix(f.lines[line], lineEnding(f.lines[line]))
+		if next < len(edits) && edits[next].Line == line {
+			e := edits[next]
+			next++
+			body.WriteString("-" + e.Old + "\n")
+			oldCount++
+			for _, added := range strings.Split(e.New, "\n") {
+				body.WriteString("+" + added + "\n")
+				newCount++
+			}
+			continue
+		}
+		body.WriteString(" " + text + "\n")
+		oldCount++
+		newCount++
+	}
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@\n%s", start+1, oldCount, start+1+shift, newCount, body.String())
+}
+
+// Diff returns the unified diff of the selected hunks, or of every hunk if selected is nil.
+func (f *FileEdit) Diff(selected []bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", filepath.ToSlash(f.Path), filepath.ToSlash(f.Path))
+	for i := range f.Hunks {
+		if selected == nil || selected[i] {
+			b.WriteString(f.HunkDiff(i, selected))
+		}
+	}
+	return b.String()
+}
+
+// Apply writes the file with the selected hunks applied, or every hunk if
+// selected is nil. The new content is written to a temporary file in the same
+// directory and renamed over the original, so the file is never left half
+// written. The file mode is kept, and the write is refused if the file changed
+// since it was planned.
+func (f *FileEdit) Apply(selected []bool) error {
+	info, err := os.Lstat(f.Path)
+	if err != nil {
+		return err
+	}
+	if !os.SameFile(info, f.info) || info.Size() != f.info.Size() || !info.ModTime().Equal(f.info.ModTime()) {
+		return fmt.Errorf("%s changed since it was read", f.Path)
+	}
+	return writeFileAtomic(f.Path, []byte(f.content(selected)), f.info.Mode())
+}
+
+// writeFileAtomic replaces path with data by writing a temporary file next to
+// it and renaming it into place.
+func writeFileAtomic(path string, data []byte, mode fs.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".f-*")
+	if err != nil {
+		return err
+	}
+	// Removing the temporary file fails harmlessly once it has been renamed
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode & (fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
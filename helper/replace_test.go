@@ -0,0 +1,132 @@
+package helper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestReplacer checks literal, case-insensitive and regex replacement.
+func TestReplacer(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		pattern, replacement string
+		opts                 MatchOptions
+		in, want             string
+		count                int
+	}{
+		{"foo", "bar", MatchOptions{}, "foo foo Foo", "bar bar Foo", 2},
+		{"foo", "$1", MatchOptions{}, "a foo", "a $1", 1},
+		{"foo", "bar", MatchOptions{IgnoreCase: true}, "foo Foo", "bar bar", 2},
+		{`(\w+)@(\w+)`, "$2 at ${1}", MatchOptions{Regex: true}, "me@host", "host at me", 1},
+		{"x", "y", MatchOptions{}, "abc", "abc", 0},
+	}
+	for _, c := range cases {
+		r, err := NewReplacer(c.pattern, c.replacement, c.opts)
+		if err != nil {
+			t.Fatalf("NewReplacer(%q) returned error: %v", c.pattern, err)
+		}
+		got, count := r.Replace(c.in)
+		if got != c.want || count != c.count {
+			t.Fatalf("Replace(%q) with %q -> %q = %q (%d), want %q (%d)", c.in, c.pattern, c.replacement, got, count, c.want, c.count)
+		}
+	}
+	if _, err := NewReplacer("*.go", "x", MatchOptions{Glob: true}); err == nil {
+		t.Fatalf("expected an error for glob patterns")
+	}
+}
+
+// TestFileEditApply verifies hunks, partial application and preservation of
+// line endings and file mode.
+func TestFileEditApply(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	path := filepath.Join(td, "crlf.txt")
+	var lines []string
+	for i := range 20 {
+		line := "line"
+		if i == 1 || i == 3 || i == 18 {
+			line = "old value"
+		}
+		lines = append(lines, line)
+	}
+	// No line ending on the last line
+	content := strings.Join(lines, "\r\n")
+	if err := os.WriteFile(path, []byte(content), 0o750); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	r, err := NewReplacer("old", "new", MatchOptions{})
+	if err != nil {
+		t.Fatalf("NewReplacer returned error: %v", err)
+	}
+
+	edit, err := PlanFileEdit(path, r)
+	if err != nil || edit == nil {
+		t.Fatalf("PlanFileEdit returned %v, %v", edit, err)
+	}
+	if len(edit.Hunks) != 2 || len(edit.Hunks[0].Edits) != 2 {
+		t.Fatalf("expected lines 2 and 4 in one hunk and line 19 in another, got %+v", edit.Hunks)
+	}
+	diff := edit.Diff(nil)
+	if !strings.Contains(diff, "@@ -1,7 +1,7 @@\n line\n-old value\n+new value\n") || !strings.Contains(diff, "@@ -16,5 +16,5 @@") {
+		t.Fatalf("unexpected diff:\n%s", diff)
+	}
+
+	// Apply only the second hunk
+	if err := edit.Apply([]bool{false, true}); err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	last := strings.LastIndex(content, "old value")
+	want := content[:last] + "new value" + content[last+len("old value"):]
+	if string(data) != want {
+		t.Fatalf("unexpected content:\n%q\nwant\n%q", data, want)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if info.Mode().Perm() != 0o750 {
+		t.Fatalf("expected mode 0750 to be kept, got %v", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(td)
+	if len(entries) != 1 {
+		t.Fatalf("expected no temporary files to be left, got %v", entries)
+	}
+
+	// The planned edit is stale once the file has been written
+	if err := edit.Apply(nil); err == nil {
+		t.Fatalf("expected an error for a file that changed since it was planned")
+	}
+}
+
+// TestPlanReplaceSkipsBinary ensures binary files are never rewritten.
+func TestPlanReplaceSkipsBinary(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	if err := os.WriteFile(filepath.Join(td, "text.txt"), []byte("needle\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(td, "data.bin"), []byte("needle\x00\x01"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	r, err := NewReplacer("needle", "pin", MatchOptions{})
+	if err != nil {
+		t.Fatalf("NewReplacer returned error: %v", err)
+	}
+	edits, err := PlanReplace(context.Background(), td, r, SearchOptions{})
+	if err != nil {
+		t.Fatalf("PlanReplace returned error: %v", err)
+	}
+	if len(edits) != 1 || filepath.Base(edits[0].Path) != "text.txt" {
+		t.Fatalf("expected only text.txt, got %v", edits)
+	}
+}
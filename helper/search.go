@@ -1,55 +1,186 @@
 package helper
 
 import (
+	"context"
 	"errors"
 	"io/fs"
-	"os"
 	"path/filepath"
-	"strings"
+	"sort"
+	"sync"
 )
 
-// SearchByName searches for files by name in a directory.
-func SearchByName(query string, dir string) ([]string, error) {
-	var results []string
-	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+// SearchOptions controls which files the search helpers visit and how queries match.
+type SearchOptions struct {
+	// RespectIgnore skips files excluded by .gitignore, .ignore and .git/info/exclude.
+	RespectIgnore bool
+	// Match selects literal, regex or glob matching and its modifiers.
+	Match MatchOptions
+	// FullPath matches names against the path relative to the search directory
+	// instead of the base name. Glob queries always match the relative path.
+	FullPath bool
+	// Before and After are the number of context lines kept around each content match.
+	Before int
+	After  int
+	// FilesWithMatches stops scanning a file at its first content match.
+	FilesWithMatches bool
+	// Binary selects how content search treats files that look binary.
+	Binary BinaryMode
+	// Encoding is the character encoding content search decodes files from.
+	Encoding Encoding
+	// Threads is the number of workers reading directories and files. Zero uses DefaultThreads.
+	Threads int
+	// SortByPath orders collected results by path. Without it, results come in
+	// whatever order the concurrent walk finds them.
+	SortByPath bool
+	// Filter limits the visited entries by type, size, age, depth and extension.
+	Filter FileFilter
+}
+
+// NameTarget returns the text a name query is matched against for path.
+func (opts SearchOptions) NameTarget(path, dir string) string {
+	if !opts.FullPath && !opts.Match.Glob {
+		return filepath.Base(path)
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// walkFiles calls fn for every entry under dir that passes opts.Filter, pruning ignored
+// subtrees when requested. Directories are only passed to fn when the filter asks for them.
+// The walk is concurrent, so fn may be called from several goroutines at once.
+// Entries that can't be read, and files for which fn fails, are collected into
+// a *WalkError while the walk carries on. Only a failure to read dir itself stops
+// it, or ctx being cancelled, in which case calls to fn already running finish
+// and the context's error is returned.
+func walkFiles(ctx context.Context, dir string, opts SearchOptions, fn func(path string, d fs.DirEntry) error) error {
+	var ignore *IgnoreMatcher
+	if opts.RespectIgnore {
+		var err error
+		ignore, err = NewIgnoreMatcher(dir)
 		if err != nil {
 			return err
 		}
-		if d.IsDir() {
+	}
+	filter := opts.Filter
+	if filter.Types == 0 {
+		filter.Types = TypeAny &^ TypeDir
+	}
+	var failures walkErrors
+	err := Walk(dir, opts.Threads, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			failures.add(path, err)
+			return nil
+		}
+		if path == dir {
 			return nil
 		}
-		if strings.Contains(d.Name(), query) {
-			results = append(results, path)
+		if ignore != nil && ignore.Ignored(path, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
 		}
-		return nil
+		// Directories at the depth limit are visited but not descended into
+		var next error
+		if d.IsDir() && filter.MaxDepth > 0 && pathDepth(dir, path) >= filter.MaxDepth {
+			next = filepath.SkipDir
+		}
+		match, err := filter.Match(path, d)
+		if err != nil {
+			failures.add(path, err)
+			return next
+		}
+		if match {
+			if err := fn(path, d); err != nil {
+				failures.add(path, err)
+			}
+		}
+		return next
 	})
-	if errors.Is(err, fs.ErrNotExist) {
-		return nil, errors.New("not found")
+	if err != nil {
+		return err
 	}
-	return results, err
+	return failures.err()
 }
 
-// SearchByContent searches for files by content in a directory.
-func SearchByContent(query string, dir string) ([]string, error) {
+// ErrNotFound is returned when the directory to search doesn't exist.
+var ErrNotFound = errors.New("not found")
+
+// notFound replaces a missing search directory with ErrNotFound.
+// Partial walk errors are returned unchanged.
+func notFound(err error) error {
+	var walkErr *WalkError
+	if errors.Is(err, fs.ErrNotExist) && !errors.As(err, &walkErr) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// SearchByName searches for files by name in a directory.
+// If some paths could not be read, the matches found elsewhere are returned along with a *WalkError.
+func SearchByName(ctx context.Context, query string, dir string, opts SearchOptions) ([]string, error) {
+	matcher, err := NewMatcher(query, opts.Match)
+	if err != nil {
+		return nil, err
+	}
+	var mu sync.Mutex
 	var results []string
-	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if d.IsDir() {
-			return nil
-		}
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return err
-		}
-		if strings.Contains(string(content), query) {
+	err = walkFiles(ctx, dir, opts, func(path string, d fs.DirEntry) error {
+		if matcher.MatchString(opts.NameTarget(path, dir)) {
+			mu.Lock()
 			results = append(results, path)
+			mu.Unlock()
 		}
 		return nil
 	})
-	if errors.Is(err, fs.ErrNotExist) {
-		return nil, errors.New("not found")
+	if opts.SortByPath {
+		sort.Strings(results)
+	}
+	return results, notFound(err)
+}
+
+// SearchByContent searches for files by content in a directory and returns the paths of files with a match.
+// If some paths could not be read, the matches found elsewhere are returned along with a *WalkError.
+func SearchByContent(ctx context.Context, query string, dir string, opts SearchOptions) ([]string, error) {
+	opts.FilesWithMatches = true
+	matches, err := SearchContent(ctx, query, dir, opts)
+	var walkErr *WalkError
+	if err != nil && !errors.As(err, &walkErr) {
+		return nil, err
+	}
+	results := make([]string, 0, len(matches))
+	for _, match := range matches {
+		results = append(results, match.Path)
 	}
 	return results, err
 }
+
+// Find returns every entry under dir that passes opts.Filter. Unlike the
+// searches, it includes directories unless the filter limits the types.
+// If some paths could not be read, the entries found elsewhere are returned along with a *WalkError.
+func Find(ctx context.Context, dir string, opts SearchOptions) ([]string, error) {
+	if opts.Filter.Types == 0 {
+		opts.Filter.Types = TypeAny
+	}
+	var mu sync.Mutex
+	var results []string
+	err := walkFiles(ctx, dir, opts, func(path string, d fs.DirEntry) error {
+		mu.Lock()
+		results = append(results, path)
+		mu.Unlock()
+		return nil
+	})
+	if opts.SortByPath {
+		sort.Strings(results)
+	}
+	return results, notFound(err)
+}
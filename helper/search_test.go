@@ -1,6 +1,8 @@
 package helper
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -30,7 +32,7 @@ func TestSearchByNameAndContent(t *testing.T) {
 	}
 
 	// Search by name: query "alpha" should find alpha.txt
-	nameResults, err := SearchByName("alpha", td)
+	nameResults, err := SearchByName(context.Background(), "alpha", td, SearchOptions{})
 	if err != nil {
 		t.Fatalf("SearchByName returned error: %v", err)
 	}
@@ -42,7 +44,7 @@ func TestSearchByNameAndContent(t *testing.T) {
 	}
 
 	// Search by content: query "needle" should find alpha and gamma
-	contentResults, err := SearchByContent("needle", td)
+	contentResults, err := SearchByContent(context.Background(), "needle", td, SearchOptions{})
 	if err != nil {
 		t.Fatalf("SearchByContent returned error: %v", err)
 	}
@@ -51,14 +53,14 @@ func TestSearchByNameAndContent(t *testing.T) {
 	}
 
 	// Search for a non-existing name/content -> expect empty results and no error
-	nres, err := SearchByName("does-not-exist", td)
+	nres, err := SearchByName(context.Background(), "does-not-exist", td, SearchOptions{})
 	if err != nil {
 		t.Fatalf("SearchByName unexpected error for no matches: %v", err)
 	}
 	if len(nres) != 0 {
 		t.Fatalf("expected zero results for SearchByName no-match, got %d", len(nres))
 	}
-	cres, err := SearchByContent("nope-nope", td)
+	cres, err := SearchByContent(context.Background(), "nope-nope", td, SearchOptions{})
 	if err != nil {
 		t.Fatalf("SearchByContent unexpected error for no matches: %v", err)
 	}
@@ -66,3 +68,21 @@ func TestSearchByNameAndContent(t *testing.T) {
 		t.Fatalf("expected zero results for SearchByContent no-match, got %d", len(cres))
 	}
 }
+
+// TestSearchCancelled verifies that a search stops once its context is cancelled.
+func TestSearchCancelled(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	if err := os.WriteFile(filepath.Join(td, "alpha.txt"), []byte("needle"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := SearchByName(ctx, "alpha", td, SearchOptions{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the name search to be cancelled, got %v", err)
+	}
+	if _, err := SearchByContent(ctx, "needle", td, SearchOptions{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the content search to be cancelled, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package helper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SizeUnits selects how sizes are formatted.
+type SizeUnits int
+
+const (
+	// UnitsIEC uses powers of 1024 labeled KiB, MiB, GiB, TiB and PiB.
+	UnitsIEC SizeUnits = iota
+	// UnitsSI uses powers of 1000 labeled kB, MB, GB, TB and PB.
+	UnitsSI
+	// UnitsBytes prints the raw number of bytes.
+	UnitsBytes
+)
+
+var (
+	iecLabels = []string{"KiB", "MiB", "GiB", "TiB", "PiB"}
+	siLabels  = []string{"kB", "MB", "GB", "TB", "PB"}
+)
+
+// ParseSizeUnits parses the value of the --units flag.
+func ParseSizeUnits(s string) (SizeUnits, error) {
+	switch strings.ToLower(s) {
+	case "", "iec":
+		return UnitsIEC, nil
+	case "si":
+		return UnitsSI, nil
+	case "bytes":
+		return UnitsBytes, nil
+	}
+	return UnitsIEC, fmt.Errorf("invalid units %q (expected iec, si or bytes)", s)
+}
+
+// FormatSizeUnits formats the size in bytes using the given units.
+func FormatSizeUnits(size int64, units SizeUnits) string {
+	if units == UnitsBytes {
+		return strconv.FormatInt(size, 10)
+	}
+	base, labels := float64(1024), iecLabels
+	if units == UnitsSI {
+		base, labels = 1000, siLabels
+	}
+	if float64(size) < base {
+		return fmt.Sprintf("%d Bytes", size)
+	}
+	value := float64(size) / base
+	i := 0
+	for value >= base && i < len(labels)-1 {
+		value /= base
+		i++
+	}
+	return fmt.Sprintf("%.2f %s", value, labels[i])
+}
+
+// ParseSize parses a size such as "512", "10MiB", "1.5GB" or "4k".
+// IEC suffixes (KiB, MiB, ...) and single letters (K, M, ...) are powers of 1024,
+// while SI suffixes (kB, MB, ...) are powers of 1000. Suffixes are case-insensitive.
+func ParseSize(s string) (int64, error) {
+	text := strings.TrimSpace(s)
+	i := 0
+	for i < len(text) && (text[i] >= '0' && text[i] <= '9' || text[i] == '.') {
+		i++
+	}
+	number, suffix := text[:i], strings.ToLower(strings.TrimSpace(text[i:]))
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	multiplier := float64(1)
+	if suffix != "" && suffix != "b" && suffix != "bytes" {
+		exponent := strings.IndexByte("kmgtp", suffix[0]) + 1
+		if exponent == 0 {
+			return 0, fmt.Errorf("invalid size unit in %q", s)
+		}
+		base := float64(1024)
+		switch suffix[1:] {
+		case "", "i", "ib":
+		case "b":
+			base = 1000
+		default:
+			return 0, fmt.Errorf("invalid size unit in %q", s)
+		}
+		for range exponent {
+			multiplier *= base
+		}
+	}
+	return int64(value * multiplier), nil
+}
@@ -0,0 +1,77 @@
+package helper
+
+import "testing"
+
+// TestFormatSizeUnits checks IEC, SI and raw byte formatting at unit boundaries.
+func TestFormatSizeUnits(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		size  int64
+		units SizeUnits
+		want  string
+	}{
+		{500, UnitsIEC, "500 Bytes"},
+		{1024, UnitsIEC, "1.00 KiB"},
+		{1024 * 1024, UnitsIEC, "1.00 MiB"},
+		{1024 * 1024 * 1024, UnitsIEC, "1.00 GiB"},
+		{3 << 40, UnitsIEC, "3.00 TiB"},
+		{999, UnitsSI, "999 Bytes"},
+		{1000, UnitsSI, "1.00 kB"},
+		{2500000, UnitsSI, "2.50 MB"},
+		{4 * 1000 * 1000 * 1000 * 1000, UnitsSI, "4.00 TB"},
+		{1536, UnitsBytes, "1536"},
+		{5 << 50, UnitsIEC, "5.00 PiB"},
+	}
+	for _, c := range cases {
+		if got := FormatSizeUnits(c.size, c.units); got != c.want {
+			t.Fatalf("FormatSizeUnits(%d, %v) = %q, want %q", c.size, c.units, got, c.want)
+		}
+	}
+}
+
+// TestParseSize checks IEC, SI and single-letter suffixes as well as invalid input.
+func TestParseSize(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]int64{
+		"512":     512,
+		"10MiB":   10 << 20,
+		"10 mib":  10 << 20,
+		"1.5GB":   1500000000,
+		"2kB":     2000,
+		"4k":      4096,
+		"1T":      1 << 40,
+		"100B":    100,
+		"3 bytes": 3,
+	}
+	for in, want := range cases {
+		got, err := ParseSize(in)
+		if err != nil {
+			t.Fatalf("ParseSize(%q) returned error: %v", in, err)
+		}
+		if got != want {
+			t.Fatalf("ParseSize(%q) = %d, want %d", in, got, want)
+		}
+	}
+	for _, in := range []string{"", "MB", "10XB", "10 kilobytes", "-5"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Fatalf("expected error for %q", in)
+		}
+	}
+}
+
+// TestParseSizeUnits verifies the accepted --units values.
+func TestParseSizeUnits(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]SizeUnits{"": UnitsIEC, "iec": UnitsIEC, "SI": UnitsSI, "bytes": UnitsBytes} {
+		got, err := ParseSizeUnits(in)
+		if err != nil || got != want {
+			t.Fatalf("ParseSizeUnits(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseSizeUnits("metric"); err == nil {
+		t.Fatalf("expected error for invalid units")
+	}
+}
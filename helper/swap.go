@@ -0,0 +1,52 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// SwapPaths exchanges the files or directories at a and b, so each takes the
+// other's name. Where renameat2 supports RENAME_EXCHANGE the swap is atomic;
+// elsewhere it goes through a temporary name, and the steps already done are
+// undone if one fails.
+func SwapPaths(a, b string) error {
+	for _, path := range []string{a, b} {
+		if _, err := os.Lstat(path); err != nil {
+			return err
+		}
+	}
+	err := renameat2(a, b, renameFlagExchange)
+	if errors.Is(err, errNoRenameat2) {
+		return swapViaTemp(a, b)
+	}
+	return err
+}
+
+// swapViaTemp swaps a and b by moving a to a temporary name, b to a and the
+// temporary name to b.
+func swapViaTemp(a, b string) error {
+	temp, err := tempName(a)
+	if err != nil {
+		return err
+	}
+	if err := renameNoReplace(a, temp); err != nil {
+		return err
+	}
+	if err := renameNoReplace(b, a); err != nil {
+		if undoErr := renameNoReplace(temp, a); undoErr != nil {
+			return fmt.Errorf("%w (%s was left at %s)", err, a, temp)
+		}
+		return err
+	}
+	if err := renameNoReplace(temp, b); err != nil {
+		if undoErr := renameNoReplace(a, b); undoErr != nil {
+			return fmt.Errorf("%w (%s was left at %s and %s at %s)", err, a, temp, b, a)
+		}
+		if undoErr := renameNoReplace(temp, a); undoErr != nil {
+			return fmt.Errorf("%w (%s was left at %s)", err, a, temp)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package helper
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSwapPaths verifies that two files trade names, and that a missing file is an error.
+func TestSwapPaths(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	writeNamedFiles(t, td, "a", "b")
+	if err := os.Mkdir(filepath.Join(td, "dir"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	if err := SwapPaths(filepath.Join(td, "a"), filepath.Join(td, "b")); err != nil {
+		t.Fatalf("SwapPaths returned error: %v", err)
+	}
+	checkContents(t, td, map[string]string{"a": "b", "b": "a"})
+
+	// A file and a directory can trade names too
+	if err := SwapPaths(filepath.Join(td, "a"), filepath.Join(td, "dir")); err != nil {
+		t.Fatalf("SwapPaths returned error: %v", err)
+	}
+	checkContents(t, td, map[string]string{"dir": "b"})
+	if info, err := os.Stat(filepath.Join(td, "a")); err != nil || !info.IsDir() {
+		t.Fatalf("expected a to be the directory now, got %v, %v", info, err)
+	}
+
+	if err := SwapPaths(filepath.Join(td, "b"), filepath.Join(td, "missing")); err == nil {
+		t.Fatalf("expected an error swapping with a missing file")
+	}
+
+	// swapViaTemp is what platforms without RENAME_EXCHANGE use
+	if err := swapViaTemp(filepath.Join(td, "b"), filepath.Join(td, "dir")); err != nil {
+		t.Fatalf("swapViaTemp returned error: %v", err)
+	}
+	checkContents(t, td, map[string]string{"b": "b", "dir": "a"})
+	entries, _ := os.ReadDir(td)
+	if len(entries) != 3 {
+		t.Fatalf("expected no temporary files to remain, got %v", entries)
+	}
+}
+
+// TestRenameNoReplace verifies that an existing target is never replaced.
+func TestRenameNoReplace(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	writeNamedFiles(t, td, "a", "b")
+	if err := os.Mkdir(filepath.Join(td, "dir"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	path := func(name string) string { return filepath.Join(td, name) }
+
+	// linkRename is what platforms without renameat2 use
+	for name, rename := range map[string]func(from, to string) error{"renameNoReplace": renameNoReplace, "linkRename": linkRename} {
+		for _, from := range []string{"a", "dir"} {
+			if err := rename(path(from), path("b")); !errors.Is(err, fs.ErrExist) {
+				t.Fatalf("%s(%s, b): expected an exists error, got %v", name, from, err)
+			}
+		}
+		checkContents(t, td, map[string]string{"a": "a", "b": "b"})
+
+		if err := rename(path("a"), path("c")); err != nil {
+			t.Fatalf("%s returned error: %v", name, err)
+		}
+		checkContents(t, td, map[string]string{"c": "a"})
+		if err := rename(path("dir"), path("dir2")); err != nil {
+			t.Fatalf("%s returned error moving a directory: %v", name, err)
+		}
+		// Put everything back for the next function
+		if err := os.Rename(path("c"), path("a")); err != nil {
+			t.Fatalf("rename back: %v", err)
+		}
+		if err := os.Rename(path("dir2"), path("dir")); err != nil {
+			t.Fatalf("rename back: %v", err)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package helper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// templatePart is a literal piece of a rename template or a placeholder.
+type templatePart struct {
+	literal string
+	field   string
+	arg     string
+	filters []string
+}
+
+// RenameTemplate builds new file names from placeholders like {name}, {ext},
+// {n:03}, {date:2006-01-02}, {parent}, {size} and {hash:8}, each optionally
+// followed by filters like {name|lower}.
+type RenameTemplate struct {
+	parts []templatePart
+	// Start is the sequence number given to the first file.
+	Start int
+}
+
+// templateFields are the placeholders a template can use.
+var templateFields = map[string]bool{"name": true, "ext": true, "n": true, "date": true, "parent": true, "size": true, "hash": true}
+
+// nameFilters are the case transforms a placeholder can be piped through.
+var nameFilters = map[string]func(string) string{
+	"lower":   strings.ToLower,
+	"upper":   strings.ToUpper,
+	"title":   titleCase,
+	"snake":   func(s string) string { return strings.Join(splitWords(s), "_") },
+	"kebab":   func(s string) string { return strings.Join(splitWords(s), "-") },
+	"slugify": slugify,
+	"slug":    slugify, // short alias of slugify
+}
+
+// ParseRenameTemplate parses a rename template. Literal braces are written as {{ and }}.
+func ParseRenameTemplate(template string) (*RenameTemplate, error) {
+	t := &RenameTemplate{Start: 1}
+	var literal strings.Builder
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		switch {
+		case c == '{' && strings.HasPrefix(template[i:], "{{"), c == '}' && strings.HasPrefix(template[i:], "}}"):
+			literal.WriteByte(c)
+			i++
+		case c == '}':
+			return nil, fmt.Errorf("unmatched } in template %q", template)
+		case c == '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed placeholder in template %q", template)
+			}
+			part, err := parsePlaceholder(template[i+1 : i+end])
+			if err != nil {
+				return nil, err
+			}
+			if literal.Len() > 0 {
+				t.parts = append(t.parts, templatePart{literal: literal.String()})
+				literal.Reset()
+			}
+			t.parts = append(t.parts, part)
+			i += end
+		default:
+			literal.WriteByte(c)
+		}
+	}
+	if literal.Len() > 0 {
+		t.parts = append(t.parts, templatePart{literal: literal.String()})
+	}
+	return t, nil
+}
+
+// parsePlaceholder parses the inside of a placeholder: field[:arg][|filter]...
+func parsePlaceholder(s string) (templatePart, error) {
+	pieces := strings.Split(s, "|")
+	part := templatePart{field: pieces[0], filters: pieces[1:]}
+	if field, arg, ok := strings.Cut(part.field, ":"); ok {
+		part.field, part.arg = field, arg
+	}
+	if !templateFields[part.field] {
+		return part, fmt.Errorf("unknown placeholder {%s} (expected name, ext, n, date, parent, size or hash)", part.field)
+	}
+	switch part.field {
+	case "n":
+		if part.arg != "" {
+			if width, err := strconv.Atoi(part.arg); err != nil || width < 0 {
+				return part, fmt.Errorf("invalid width %q in {n:%s}", part.arg, part.arg)
+			}
+		}
+	case "hash":
+		if part.arg != "" {
+			if length, err := strconv.Atoi(part.arg); err != nil || length < 1 || length > sha256.Size*2 {
+				return part, fmt.Errorf("invalid length %q in {hash:%s}", part.arg, part.arg)
+			}
+		}
+	}
+	for _, filter := range part.filters {
+		if nameFilters[filter] == nil {
+			return part, fmt.Errorf("unknown filter %q (expected lower, upper, title, snake, kebab or slugify)", filter)
+		}
+	}
+	return part, nil
+}
+
+// Execute returns the new name for path, the index'th file of the batch.
+func (t *RenameTemplate) Execute(path string, index int) (string, error) {
+	var b strings.Builder
+	var info os.FileInfo
+	for _, part := range t.parts {
+		if part.field == "" {
+			b.WriteString(part.literal)
+			continue
+		}
+		if info == nil {
+			var err error
+			if info, err = os.Stat(path); err != nil {
+				return "", err
+			}
+		}
+		value, err := t.value(part, path, index, info)
+		if err != nil {
+			return "", err
+		}
+		for _, filter := range part.filters {
+			value = nameFilters[filter](value)
+		}
+		b.WriteString(value)
+	}
+	return b.String(), nil
+}
+
+// value returns the text of a placeholder before filters are applied.
+func (t *RenameTemplate) value(part templatePart, path string, index int, info os.FileInfo) (string, error) {
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	if info.IsDir() || ext == base {
+		// Directories and dotfiles like .bashrc have no extension
+		ext = ""
+	}
+	switch part.field {
+	case "name":
+		return strings.TrimSuffix(base, ext), nil
+	case "ext":
+		return ext, nil
+	case "n":
+		width, _ := strconv.Atoi(part.arg)
+		return fmt.Sprintf("%0*d", width, t.Start+index), nil
+	case "date":
+		layout := part.arg
+		if layout == "" {
+			layout = "2006-01-02"
+		}
+		return info.ModTime().Format(layout), nil
+	case "parent":
+		return filepath.Base(filepath.Dir(path)), nil
+	case "size":
+		return strconv.FormatInt(info.Size(), 10), nil
+	case "hash":
+		if !info.Mode().IsRegular() {
+			return "", fmt.Errorf("{hash} needs a regular file")
+		}
+		length := 8
+		if part.arg != "" {
+			length, _ = strconv.Atoi(part.arg)
+		}
+		return fileHash(path, length)
+	}
+	return "", fmt.Errorf("unknown placeholder {%s}", part.field)
+}
+
+// fileHash returns the first length hex digits of the SHA-256 of the file at path.
+func fileHash(path string, length int) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:length], nil
+}
+
+// splitWords splits s into lower-case words at separators, camel-case humps
+// and acronym boundaries, so "myHTTPServer v2.log" gives my, http, server, v2, log.
+func splitWords(s string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(s)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
+
+// titleCase capitalizes the first letter of every word and lower-cases the rest.
+func titleCase(s string) string {
+	var b strings.Builder
+	start := true
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start {
+				b.WriteRune(unicode.ToUpper(r))
+			} else {
+				b.WriteRune(unicode.ToLower(r))
+			}
+			start = false
+			continue
+		}
+		b.WriteRune(r)
+		start = true
+	}
+	return b.String()
+}
+
+// accentFolder replaces common accented Latin letters with their ASCII base letter.
+var accentFolder = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a", "æ", "ae",
+	"ç", "c", "è", "e", "é", "e", "ê", "e", "ë", "e",
+	"ì", "i", "í", "i", "î", "i", "ï", "i", "ñ", "n",
+	"ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o", "ø", "o", "œ", "oe",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u", "ý", "y", "ÿ", "y", "ß", "ss",
+)
+
+// slugify lower-cases s, folds accents and joins the remaining ASCII letters
+// and digits with single dashes.
+func slugify(s string) string {
+	s = accentFolder.Replace(strings.ToLower(s))
+	var b strings.Builder
+	dash := false
+	for _, r := range s {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
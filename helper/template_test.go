@@ -0,0 +1,81 @@
+package helper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestNameFilters checks the case transforms.
+func TestNameFilters(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		filter, in, want string
+	}{
+		{"lower", "Hello World", "hello world"},
+		{"upper", "Hello", "HELLO"},
+		{"title", "hello wide-world", "Hello Wide-World"},
+		{"snake", "myHTTPServer v2.log", "my_http_server_v2_log"},
+		{"kebab", "Quarterly Report_FINAL", "quarterly-report-final"},
+		{"slugify", "  Crème Brûlée: 2 ways! ", "creme-brulee-2-ways"},
+		{"slug", "Crème Brûlée", "creme-brulee"},
+	}
+	for _, c := range cases {
+		if got := nameFilters[c.filter](c.in); got != c.want {
+			t.Fatalf("%s(%q) = %q, want %q", c.filter, c.in, got, c.want)
+		}
+	}
+}
+
+// TestRenameTemplate checks every placeholder against a real file.
+func TestRenameTemplate(t *testing.T) {
+	t.Parallel()
+
+	td := t.TempDir()
+	dir := filepath.Join(td, "Holiday Photos")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	path := filepath.Join(dir, "IMG Beach.PNG")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	mtime := time.Date(2023, 7, 14, 9, 30, 0, 0, time.Local)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+
+	cases := []struct {
+		template string
+		start    int
+		index    int
+		want     string
+	}{
+		{"{date:2006-01-02}_{n:03}_{name|lower}{ext}", 1, 4, "2023-07-14_005_img beach.PNG"},
+		{"{parent|slugify}-{name|kebab}{ext|lower}", 1, 0, "holiday-photos-img-beach.png"},
+		{"{n}-{size}-{hash:6}{ext}", 10, 0, "10-5-2cf24d.PNG"},
+		{"{{literal}}-{date:15h04}", 1, 0, "{literal}-09h30"},
+	}
+	for _, c := range cases {
+		tmpl, err := ParseRenameTemplate(c.template)
+		if err != nil {
+			t.Fatalf("ParseRenameTemplate(%q) returned error: %v", c.template, err)
+		}
+		tmpl.Start = c.start
+		got, err := tmpl.Execute(path, c.index)
+		if err != nil {
+			t.Fatalf("Execute(%q) returned error: %v", c.template, err)
+		}
+		if got != c.want {
+			t.Fatalf("Execute(%q) = %q, want %q", c.template, got, c.want)
+		}
+	}
+
+	for _, bad := range []string{"{nope}", "{name|shout}", "{name", "name}", "{n:x}", "{hash:0}"} {
+		if _, err := ParseRenameTemplate(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
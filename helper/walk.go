@@ -0,0 +1,192 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// walkJob is an entry waiting to be visited.
+type walkJob struct {
+	path string
+	d    fs.DirEntry
+	// parent is the directory the entry was read from, or nil for the root.
+	parent *walkDir
+}
+
+// walkDir is the state shared by the entries read from one directory.
+type walkDir struct {
+	// skipped is set once fn returns filepath.SkipDir for one of the entries.
+	skipped atomic.Bool
+}
+
+// walker holds the shared state of a concurrent walk.
+type walker struct {
+	fn      fs.WalkDirFunc
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []walkJob
+	pending int
+	err     error
+}
+
+// DefaultThreads returns the worker count used when none is given.
+func DefaultThreads() int {
+	return runtime.NumCPU()
+}
+
+// Walk visits root and everything below it like filepath.WalkDir, but fans
+// the work out over a pool of threads workers. Directory reads and the calls
+// to fn for different entries run concurrently, so fn must be safe for
+// concurrent use, and entries are visited in no particular order. A directory
+// is always visited before its contents. Returning filepath.SkipDir from fn
+// for a directory skips its contents, and for any other entry skips the
+// entries of its directory not visited yet; any other error stops the walk and
+// is returned. If threads is less than 1, DefaultThreads is used.
+func Walk(root string, threads int, fn fs.WalkDirFunc) error {
+	info, err := os.Lstat(root)
+	if err != nil {
+		return fn(root, nil, err)
+	}
+	if threads < 1 {
+		threads = DefaultThreads()
+	}
+
+	w := &walker{fn: fn, pending: 1}
+	w.cond = sync.NewCond(&w.mu)
+	w.queue = append(w.queue, walkJob{path: root, d: fs.FileInfoToDirEntry(info)})
+
+	var wg sync.WaitGroup
+	for range threads {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.work()
+		}()
+	}
+	wg.Wait()
+
+	if w.err == filepath.SkipDir || w.err == filepath.SkipAll {
+		return nil
+	}
+	return w.err
+}
+
+// work takes jobs from the queue until the walk is finished or has failed.
+func (w *walker) work() {
+	for {
+		w.mu.Lock()
+		for len(w.queue) == 0 && w.pending > 0 && w.err == nil {
+			w.cond.Wait()
+		}
+		if w.pending == 0 || w.err != nil {
+			w.mu.Unlock()
+			w.cond.Broadcast()
+			return
+		}
+		// Taking the newest job walks depth first, which keeps the queue short
+		job := w.queue[len(w.queue)-1]
+		w.queue = w.queue[:len(w.queue)-1]
+		w.mu.Unlock()
+
+		children, err := w.visit(job)
+
+		w.mu.Lock()
+		if err != nil && w.err == nil {
+			w.err = err
+		}
+		w.queue = append(w.queue, children...)
+		w.pending += len(children) - 1
+		w.mu.Unlock()
+		w.cond.Broadcast()
+	}
+}
+
+// visit calls fn for one entry and, for directories, returns their contents.
+func (w *walker) visit(job walkJob) ([]walkJob, error) {
+	if job.parent != nil && job.parent.skipped.Load() {
+		return nil, nil
+	}
+	err := w.fn(job.path, job.d, nil)
+	if err == filepath.SkipDir {
+		// Like filepath.WalkDir, skipping a file skips the rest of its directory
+		if !job.d.IsDir() && job.parent != nil {
+			job.parent.skipped.Store(true)
+		}
+		return nil, nil
+	}
+	if err != nil || !job.d.IsDir() {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(job.path)
+	if err != nil {
+		// Like filepath.WalkDir, report the read error with a second call for the directory
+		err = w.fn(job.path, job.d, err)
+		if err == filepath.SkipDir {
+			err = nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	dir := &walkDir{}
+	children := make([]walkJob, 0, len(entries))
+	for _, entry := range entries {
+		children = append(children, walkJob{path: filepath.Join(job.path, entry.Name()), d: entry, parent: dir})
+	}
+	return children, nil
+}
+
+// WalkError reports the paths that could not be read during a walk. Walks
+// keep going past these paths, so results returned alongside a WalkError are
+// partial rather than missing.
+type WalkError struct {
+	Errors []error
+}
+
+func (e *WalkError) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+	return fmt.Sprintf("%d paths could not be read", len(e.Errors))
+}
+
+// Unwrap returns the individual path errors.
+func (e *WalkError) Unwrap() []error {
+	return e.Errors
+}
+
+// walkErrors collects path errors from concurrent walk callbacks.
+type walkErrors struct {
+	mu     sync.Mutex
+	errors []error
+}
+
+// add records err, adding path if err doesn't already name it.
+func (c *walkErrors) add(path string, err error) {
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) {
+		err = &fs.PathError{Op: "walk", Path: path, Err: err}
+	}
+	c.mu.Lock()
+	c.errors = append(c.errors, err)
+	c.mu.Unlock()
+}
+
+// err returns a *WalkError holding the collected errors, or nil if there were none.
+func (c *walkErrors) err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.errors) == 0 {
+		return nil
+	}
+	sort.Slice(c.errors, func(i, j int) bool { return c.errors[i].Error() < c.errors[j].Error() })
+	return &WalkError{Errors: c.errors}
+}
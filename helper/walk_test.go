@@ -0,0 +1,244 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// makeWalkTree creates dirs directories each holding files files.
+func makeWalkTree(t *testing.T, dirs, files int) string {
+	t.Helper()
+	td := t.TempDir()
+	for i := range dirs {
+		dir := filepath.Join(td, fmt.Sprintf("d%02d", i), "nested")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		for j := range files {
+			if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%02d.txt", j)), []byte("x"), 0o644); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+		}
+	}
+	return td
+}
+
+// TestWalkVisitsEverything verifies that every entry is visited exactly once
+// and that directories are visited before their contents.
+func TestWalkVisitsEverything(t *testing.T) {
+	t.Parallel()
+
+	td := makeWalkTree(t, 10, 5)
+	var mu sync.Mutex
+	visited := map[string]int{}
+	err := Walk(td, 4, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if path != td {
+			if _, ok := visited[filepath.Dir(path)]; !ok {
+				t.Errorf("visited %s before its parent", path)
+			}
+		}
+		visited[path]++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk returned error: %v", err)
+	}
+	// root + 10 dirs + 10 nested dirs + 50 files
+	if len(visited) != 71 {
+		t.Fatalf("expected 71 entries, got %d", len(visited))
+	}
+	for path, n := range visited {
+		if n != 1 {
+			t.Fatalf("%s visited %d times", path, n)
+		}
+	}
+}
+
+// TestWalkSkipDirAndErrors checks pruning with SkipDir and that errors stop the walk.
+func TestWalkSkipDirAndErrors(t *testing.T) {
+	t.Parallel()
+
+	td := makeWalkTree(t, 3, 2)
+	var mu sync.Mutex
+	count := 0
+	err := Walk(td, 2, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == "nested" {
+			return filepath.SkipDir
+		}
+		mu.Lock()
+		count++
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk returned error: %v", err)
+	}
+	if count != 4 {
+		t.Fatalf("expected root and 3 dirs to be visited, got %d", count)
+	}
+
+	wantErr := errors.New("stop")
+	err = Walk(td, 2, func(path string, d fs.DirEntry, err error) error {
+		if !d.IsDir() {
+			return wantErr
+		}
+		return nil
+	})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("expected walk error to be returned, got %v", err)
+	}
+
+	if err := Walk(filepath.Join(td, "missing"), 2, func(path string, d fs.DirEntry, err error) error { return err }); err == nil {
+		t.Fatalf("expected error for missing root")
+	}
+}
+
+// TestWalkSkipDirFromFile checks that returning SkipDir for a file skips the
+// rest of its directory, as filepath.WalkDir does.
+func TestWalkSkipDirFromFile(t *testing.T) {
+	t.Parallel()
+
+	td := makeWalkTree(t, 3, 5)
+	var mu sync.Mutex
+	files := 0
+	// A single worker visits the entries of a directory one after another
+	err := Walk(td, 1, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		mu.Lock()
+		files++
+		mu.Unlock()
+		return filepath.SkipDir
+	})
+	if err != nil {
+		t.Fatalf("Walk returned error: %v", err)
+	}
+	if files != 3 {
+		t.Fatalf("expected one file per directory to be visited, got %d", files)
+	}
+}
+
+// TestGetDirectoryTreeOrder checks that the concurrently read tree comes out
+// in the order filepath.WalkDir visits it.
+func TestGetDirectoryTreeOrder(t *testing.T) {
+	t.Parallel()
+
+	td := makeWalkTree(t, 4, 3)
+	for _, name := range []string{"d00.txt", "d00-b", "d01/nested.txt"} {
+		if err := os.WriteFile(filepath.Join(td, name), []byte("x"), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	var want []string
+	if err := filepath.WalkDir(td, func(path string, d fs.DirEntry, err error) error {
+		want = append(want, path)
+		return err
+	}); err != nil {
+		t.Fatalf("WalkDir returned error: %v", err)
+	}
+
+	tree, err := GetDirectoryTree(context.Background(), td, false, false)
+	if err != nil {
+		t.Fatalf("GetDirectoryTree returned error: %v", err)
+	}
+	var got []string
+	for _, entry := range tree {
+		got = append(got, entry.FullPath)
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("tree order = %v, want %v", got, want)
+	}
+}
+
+// TestSearchSortByPath ensures concurrent results are ordered when SortByPath is set.
+func TestSearchSortByPath(t *testing.T) {
+	t.Parallel()
+
+	td := makeWalkTree(t, 8, 4)
+	results, err := SearchByName(context.Background(), ".txt", td, SearchOptions{Threads: 8, SortByPath: true})
+	if err != nil {
+		t.Fatalf("SearchByName returned error: %v", err)
+	}
+	if len(results) != 32 || !sort.StringsAreSorted(results) {
+		t.Fatalf("expected 32 sorted results, got %v", results)
+	}
+
+	content, err := SearchContent(context.Background(), "x", td, SearchOptions{Threads: 8, SortByPath: true})
+	if err != nil {
+		t.Fatalf("SearchContent returned error: %v", err)
+	}
+	if len(content) != 32 || !sort.SliceIsSorted(content, func(i, j int) bool { return content[i].Path < content[j].Path }) {
+		t.Fatalf("expected 32 sorted content results")
+	}
+}
+
+// TestWalkUnreadableDirectory checks that searches, trees and usage reports skip
+// a directory that can't be read and report it in a *WalkError.
+func TestWalkUnreadableDirectory(t *testing.T) {
+	t.Parallel()
+	if os.Geteuid() == 0 {
+		t.Skip("permission checks don't apply to root")
+	}
+
+	td := makeWalkTree(t, 2, 1)
+	locked := filepath.Join(td, "locked")
+	if err := os.Mkdir(locked, 0o755); err != nil {
+		t.Fatalf("mkdir locked: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(locked, "hidden.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("write hidden.txt: %v", err)
+	}
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatalf("chmod locked: %v", err)
+	}
+	t.Cleanup(func() { os.Chmod(locked, 0o755) })
+
+	checkErr := func(name string, err error) {
+		t.Helper()
+		var walkErr *WalkError
+		if !errors.As(err, &walkErr) || len(walkErr.Errors) != 1 {
+			t.Fatalf("%s: expected a WalkError for the locked directory, got %v", name, err)
+		}
+		if !strings.Contains(walkErr.Error(), "locked") {
+			t.Fatalf("%s: expected the error to name the locked directory, got %v", name, walkErr)
+		}
+	}
+
+	results, err := SearchByName(context.Background(), ".txt", td, SearchOptions{Threads: 2})
+	checkErr("SearchByName", err)
+	if len(results) != 2 {
+		t.Fatalf("expected the 2 readable files, got %v", results)
+	}
+
+	tree, err := GetDirectoryTree(context.Background(), td, false, false)
+	checkErr("GetDirectoryTree", err)
+	if len(tree) == 0 {
+		t.Fatalf("expected partial tree entries")
+	}
+
+	report, err := DiskUsage(context.Background(), td, 2)
+	checkErr("DiskUsage", err)
+	if !report.Root().IsDir {
+		t.Fatalf("expected a partial usage report")
+	}
+}